@@ -6,7 +6,9 @@ import (
 	"compass-backend/config"
 	"compass-backend/db"
 	"compass-backend/internal/middleware"
+	"compass-backend/internal/repositories"
 	"compass-backend/internal/routes"
+	"compass-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,6 +17,16 @@ func main() {
 	// Load configuration
 	cfg := config.Load()
 
+	// Refuse unsafe settings in release mode
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	// Load token signing keys
+	if err := cfg.JWT.LoadSigningKeys(); err != nil {
+		log.Fatalf("Failed to load signing keys: %v", err)
+	}
+
 	// Initialize database
 	if err := db.Init(cfg); err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
@@ -25,12 +37,29 @@ func main() {
 		log.Fatalf("Failed to seed admin user: %v", err)
 	}
 
+	// Seed platform super-admin
+	if err := db.SeedSuperAdmin(db.GetDB(), cfg); err != nil {
+		log.Fatalf("Failed to seed super-admin user: %v", err)
+	}
+
+	// Purge deleted projects once their retention period has passed
+	if cfg.Project.DeletedRetention > 0 {
+		purgeService := services.NewProjectPurgeService(repositories.NewProjectRepository(db.GetDB()), cfg)
+		services.StartProjectPurge(purgeService, cfg.Project.PurgeInterval)
+	}
+
 	// Set Gin mode
 	gin.SetMode(cfg.Server.Mode)
 
 	// Initialize Gin router
 	router := gin.New()
 
+	// Only believe X-Forwarded-For from known proxies, so that clients cannot
+	// choose the IP address used for throttling and auditing
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
 	// Global middleware
 	router.Use(middleware.LoggerMiddleware())
 	router.Use(middleware.ErrorHandler())
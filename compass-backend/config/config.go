@@ -11,10 +11,21 @@ import (
 )
 
 type Config struct {
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Server   ServerConfig
-	Admin    AdminConfig
+	Database     DatabaseConfig
+	JWT          JWTConfig
+	Server       ServerConfig
+	Admin        AdminConfig
+	SuperAdmin   AdminConfig
+	Mail         MailConfig
+	Invite       InviteConfig
+	Reset        PasswordResetConfig
+	MFA          MFAConfig
+	Lockout      LockoutConfig
+	OIDC         OIDCConfig
+	Password     PasswordPolicyConfig
+	PasswordHash PasswordHashConfig
+	Profile      ProfileConfig
+	Project      ProjectConfig
 }
 
 type DatabaseConfig struct {
@@ -25,15 +36,30 @@ type DatabaseConfig struct {
 	Name     string
 }
 
+// JWTConfig configures token signing. Tokens are signed with HS256 and Secret
+// unless ActiveKeyID names an RSA or Ed25519 key in KeysDir. Secret also signs
+// invite and password reset links.
 type JWTConfig struct {
-	Secret               string
-	AccessTokenDuration  time.Duration
-	RefreshTokenDuration time.Duration
+	Secret                     string
+	AccessTokenDuration        time.Duration
+	RefreshTokenDuration       time.Duration
+	MFATokenDuration           time.Duration
+	ImpersonationTokenDuration time.Duration
+	KeysDir                    string
+	ActiveKeyID                string
+	Audience                   string
+	RetiredKeys                map[string]time.Time
+	UpcomingKeys               []string
+	KeyGracePeriod             time.Duration
+	SigningKeys                []SigningKey
 }
 
+// ServerConfig configures the HTTP server. Client IPs are only taken from
+// X-Forwarded-For when the request comes from one of TrustedProxies.
 type ServerConfig struct {
-	Port string
-	Mode string
+	Port           string
+	Mode           string
+	TrustedProxies []string
 }
 
 type AdminConfig struct {
@@ -42,6 +68,96 @@ type AdminConfig struct {
 	Name     string
 }
 
+type MailConfig struct {
+	Driver       string
+	From         string
+	OutputDir    string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+type InviteConfig struct {
+	TokenDuration time.Duration
+	AcceptURL     string
+}
+
+type PasswordResetConfig struct {
+	TokenDuration time.Duration
+	ResetURL      string
+	MaxRequests   int
+	RequestWindow time.Duration
+}
+
+type MFAConfig struct {
+	Issuer string
+}
+
+type LockoutConfig struct {
+	MaxFailedAttempts int
+	BaseDuration      time.Duration
+	MaxDuration       time.Duration
+	IPMaxFailures     int
+	IPWindow          time.Duration
+}
+
+// OIDCConfig configures single sign-on. SSO is disabled when IssuerURL is
+// empty.
+type OIDCConfig struct {
+	IssuerURL             string
+	ClientID              string
+	ClientSecret          string
+	RedirectURL           string
+	Scopes                []string
+	RequestDuration       time.Duration
+	AutoProvision         bool
+	ProvisionOrganization string
+	ProvisionRole         string
+}
+
+// PasswordPolicyConfig is the policy every new password is checked against.
+// HistorySize and MaxAge are disabled when zero.
+type PasswordPolicyConfig struct {
+	MinLength            int
+	MaxLength            int
+	RequireUppercase     bool
+	RequireLowercase     bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	DisallowPersonalInfo bool
+	HistorySize          int
+	MaxAge               time.Duration
+	BreachedListDir      string
+}
+
+// PasswordHashConfig selects how new passwords are hashed. Stored hashes made
+// with other settings are upgraded when their user next signs in.
+type PasswordHashConfig struct {
+	Algorithm         string
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	BcryptCost        int
+}
+
+// ProfileConfig configures self-service profile changes.
+type ProfileConfig struct {
+	EmailChangeTokenDuration time.Duration
+	EmailChangeURL           string
+	AvatarMaxBytes           int
+}
+
+// ProjectConfig configures the project workflow. StatusTransitions maps each
+// status to the statuses a project may move to from it.
+type ProjectConfig struct {
+	StatusTransitions             map[string][]string
+	CompleteRequiresSpecification bool
+	CompleteRequiresAnsweredRFIs  bool
+	DeletedRetention              time.Duration
+	PurgeInterval                 time.Duration
+}
+
 func Load() *Config {
 	err := godotenv.Load()
 	if err != nil {
@@ -57,19 +173,105 @@ func Load() *Config {
 			Name:     getEnv("DB_NAME", "compass_db"),
 		},
 		JWT: JWTConfig{
-			Secret:               getEnv("JWT_SECRET", "default-secret-change-this"),
-			AccessTokenDuration:  parseDuration(getEnv("JWT_ACCESS_TOKEN_EXPIRY", "15m")),
-			RefreshTokenDuration: parseDuration(getEnv("JWT_REFRESH_TOKEN_EXPIRY", "7d")),
+			Secret:                     getEnv("JWT_SECRET", DefaultJWTSecret),
+			AccessTokenDuration:        parseDuration(getEnv("JWT_ACCESS_TOKEN_EXPIRY", "15m")),
+			RefreshTokenDuration:       parseDuration(getEnv("JWT_REFRESH_TOKEN_EXPIRY", "7d")),
+			MFATokenDuration:           parseDuration(getEnv("JWT_MFA_TOKEN_EXPIRY", "5m")),
+			ImpersonationTokenDuration: parseDuration(getEnv("JWT_IMPERSONATION_TOKEN_EXPIRY", "15m")),
+			KeysDir:                    getEnv("JWT_KEYS_DIR", ""),
+			ActiveKeyID:                getEnv("JWT_ACTIVE_KEY_ID", ""),
+			Audience:                   getEnv("JWT_AUDIENCE", "compass-api"),
+			RetiredKeys:                parseRetiredKeys(getEnv("JWT_RETIRED_KEYS", "")),
+			UpcomingKeys:               parseList(getEnv("JWT_UPCOMING_KEYS", "")),
+			KeyGracePeriod:             parseDuration(getEnv("JWT_KEY_GRACE_PERIOD", "7d")),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Mode: getEnv("SERVER_MODE", "debug"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			Mode:           getEnv("SERVER_MODE", "debug"),
+			TrustedProxies: parseList(getEnv("TRUSTED_PROXIES", "")),
 		},
 		Admin: AdminConfig{
 			Email:    getEnv("ADMIN_EMAIL", "admin@compass.com"),
 			Password: getEnv("ADMIN_PASSWORD", "AdminPassword123!"),
 			Name:     getEnv("ADMIN_NAME", "System Administrator"),
 		},
+		SuperAdmin: AdminConfig{
+			Email:    getEnv("SUPER_ADMIN_EMAIL", ""),
+			Password: getEnv("SUPER_ADMIN_PASSWORD", ""),
+			Name:     getEnv("SUPER_ADMIN_NAME", "Platform Administrator"),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "Compass <no-reply@compass.com>"),
+			OutputDir:    getEnv("MAIL_OUTPUT_DIR", "mail"),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     getEnv("SMTP_PORT", "1025"),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		},
+		Invite: InviteConfig{
+			TokenDuration: parseDuration(getEnv("INVITE_TOKEN_EXPIRY", "3d")),
+			AcceptURL:     getEnv("INVITE_ACCEPT_URL", "http://localhost:3000/accept-invite"),
+		},
+		Reset: PasswordResetConfig{
+			TokenDuration: parseDuration(getEnv("PASSWORD_RESET_TOKEN_EXPIRY", "1h")),
+			ResetURL:      getEnv("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+			MaxRequests:   parseInt(getEnv("PASSWORD_RESET_MAX_REQUESTS", "3")),
+			RequestWindow: parseDuration(getEnv("PASSWORD_RESET_REQUEST_WINDOW", "1h")),
+		},
+		MFA: MFAConfig{
+			Issuer: getEnv("MFA_ISSUER", "Compass"),
+		},
+		Lockout: LockoutConfig{
+			MaxFailedAttempts: parseInt(getEnv("LOCKOUT_MAX_FAILED_ATTEMPTS", "5")),
+			BaseDuration:      parseDuration(getEnv("LOCKOUT_BASE_DURATION", "1m")),
+			MaxDuration:       parseDuration(getEnv("LOCKOUT_MAX_DURATION", "1h")),
+			IPMaxFailures:     parseInt(getEnv("LOCKOUT_IP_MAX_FAILURES", "20")),
+			IPWindow:          parseDuration(getEnv("LOCKOUT_IP_WINDOW", "15m")),
+		},
+		OIDC: OIDCConfig{
+			IssuerURL:             getEnv("OIDC_ISSUER_URL", ""),
+			ClientID:              getEnv("OIDC_CLIENT_ID", ""),
+			ClientSecret:          getEnv("OIDC_CLIENT_SECRET", ""),
+			RedirectURL:           getEnv("OIDC_REDIRECT_URL", "http://localhost:3000/sso/callback"),
+			Scopes:                strings.Fields(getEnv("OIDC_SCOPES", "openid email profile")),
+			RequestDuration:       parseDuration(getEnv("OIDC_REQUEST_EXPIRY", "10m")),
+			AutoProvision:         parseBool(getEnv("OIDC_AUTO_PROVISION", "false")),
+			ProvisionOrganization: getEnv("OIDC_PROVISION_ORGANIZATION", "default"),
+			ProvisionRole:         getEnv("OIDC_PROVISION_ROLE", "user"),
+		},
+		Password: PasswordPolicyConfig{
+			MinLength:            parseInt(getEnv("PASSWORD_MIN_LENGTH", "8")),
+			MaxLength:            parseInt(getEnv("PASSWORD_MAX_LENGTH", "128")),
+			RequireUppercase:     parseBool(getEnv("PASSWORD_REQUIRE_UPPERCASE", "true")),
+			RequireLowercase:     parseBool(getEnv("PASSWORD_REQUIRE_LOWERCASE", "true")),
+			RequireDigit:         parseBool(getEnv("PASSWORD_REQUIRE_DIGIT", "true")),
+			RequireSymbol:        parseBool(getEnv("PASSWORD_REQUIRE_SYMBOL", "false")),
+			DisallowPersonalInfo: parseBool(getEnv("PASSWORD_DISALLOW_PERSONAL_INFO", "true")),
+			HistorySize:          parseInt(getEnv("PASSWORD_HISTORY_SIZE", "5")),
+			MaxAge:               parseOptionalDuration(getEnv("PASSWORD_MAX_AGE", "0")),
+			BreachedListDir:      getEnv("PASSWORD_BREACHED_LIST_DIR", ""),
+		},
+		PasswordHash: PasswordHashConfig{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			Argon2Memory:      uint32(parseInt(getEnv("ARGON2_MEMORY_KIB", "19456"))),
+			Argon2Iterations:  uint32(parseInt(getEnv("ARGON2_ITERATIONS", "2"))),
+			Argon2Parallelism: uint8(parseInt(getEnv("ARGON2_PARALLELISM", "1"))),
+			BcryptCost:        parseInt(getEnv("BCRYPT_COST", "10")),
+		},
+		Profile: ProfileConfig{
+			EmailChangeTokenDuration: parseDuration(getEnv("EMAIL_CHANGE_TOKEN_EXPIRY", "1d")),
+			EmailChangeURL:           getEnv("EMAIL_CHANGE_CONFIRM_URL", "http://localhost:3000/confirm-email"),
+			AvatarMaxBytes:           parseInt(getEnv("AVATAR_MAX_BYTES", "1048576")),
+		},
+		Project: ProjectConfig{
+			StatusTransitions: parseTransitions(getEnv("PROJECT_STATUS_TRANSITIONS",
+				"not_yet_started:progress,progress:completed,progress:not_yet_started,completed:progress")),
+			CompleteRequiresSpecification: parseBool(getEnv("PROJECT_COMPLETE_REQUIRES_SPECIFICATION", "true")),
+			CompleteRequiresAnsweredRFIs:  parseBool(getEnv("PROJECT_COMPLETE_REQUIRES_ANSWERED_RFIS", "true")),
+			DeletedRetention:              parseStrictDuration(getEnv("PROJECT_DELETED_RETENTION", "30d")),
+			PurgeInterval:                 parseStrictDuration(getEnv("PROJECT_PURGE_INTERVAL", "1h")),
+		},
 	}
 }
 
@@ -88,11 +290,87 @@ func parseDuration(s string) time.Duration {
 			return time.Duration(d) * 24 * time.Hour
 		}
 	}
-	
+
 	duration, err := time.ParseDuration(s)
 	if err != nil {
 		log.Printf("Error parsing duration %s: %v", s, err)
 		return 15 * time.Minute
 	}
 	return duration
-}
\ No newline at end of file
+}
+
+// parseOptionalDuration is parseDuration for settings that "0" turns off.
+func parseOptionalDuration(s string) time.Duration {
+	if s == "0" {
+		return 0
+	}
+	return parseDuration(s)
+}
+
+// parseStrictDuration is parseDuration for settings where falling back to a
+// default could destroy data. Invalid values become -1, which Validate
+// refuses.
+func parseStrictDuration(s string) time.Duration {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if d, err := strconv.Atoi(days); err == nil {
+			return time.Duration(d) * 24 * time.Hour
+		}
+	}
+
+	duration, err := time.ParseDuration(s)
+	if err != nil {
+		log.Printf("Error parsing duration %s: %v", s, err)
+		return -1
+	}
+	return duration
+}
+
+func parseInt(s string) int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		log.Printf("Error parsing integer %s: %v", s, err)
+		return 0
+	}
+	return i
+}
+
+// parseList reads a comma-separated list, returning nil when it is empty.
+func parseList(s string) []string {
+	var values []string
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// parseTransitions reads a comma-separated list of from:to status pairs.
+// Malformed entries are kept as a transition to "", which Validate refuses.
+func parseTransitions(s string) map[string][]string {
+	transitions := make(map[string][]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		from, to, ok := strings.Cut(entry, ":")
+		if !ok {
+			transitions[entry] = append(transitions[entry], "")
+			continue
+		}
+		from = strings.TrimSpace(from)
+		transitions[from] = append(transitions[from], strings.TrimSpace(to))
+	}
+	return transitions
+}
+
+func parseBool(s string) bool {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		log.Printf("Error parsing boolean %s: %v", s, err)
+		return false
+	}
+	return b
+}
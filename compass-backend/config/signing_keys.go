@@ -0,0 +1,185 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultJWTSecret is used when JWT_SECRET is not set. It is refused in
+// release mode.
+const DefaultJWTSecret = "default-secret-change-this"
+
+// exampleJWTSecret is the placeholder shipped in .env.example.
+const exampleJWTSecret = "your-super-secret-jwt-key-change-this-in-production"
+
+// SigningKey is an asymmetric key used to sign or verify tokens. Private is nil
+// for keys that are only kept to verify tokens issued before a rotation.
+// Upcoming keys are published but do not verify tokens yet.
+type SigningKey struct {
+	ID        string
+	Private   crypto.Signer
+	Public    crypto.PublicKey
+	RetiredAt *time.Time
+	Upcoming  bool
+}
+
+// LoadSigningKeys reads every "<kid>.pem" file of KeysDir. Tokens are signed
+// with ActiveKeyID; keys listed in UpcomingKeys are published ahead of a
+// rotation, and keys listed in RetiredKeys only verify tokens and stop doing
+// so once their grace period after RetiredAt has passed. Any other key in the
+// directory is refused, so that dropping a file there cannot make the service
+// trust it.
+func (c *JWTConfig) LoadSigningKeys() error {
+	if c.ActiveKeyID == "" {
+		return nil
+	}
+	if c.KeysDir == "" {
+		return errors.New("JWT_KEYS_DIR is required when JWT_ACTIVE_KEY_ID is set")
+	}
+
+	files, err := filepath.Glob(filepath.Join(c.KeysDir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make([]SigningKey, 0, len(files))
+	for _, file := range files {
+		key, err := readSigningKey(file)
+		if err != nil {
+			return fmt.Errorf("signing key %s: %w", file, err)
+		}
+		if retiredAt, ok := c.RetiredKeys[key.ID]; ok {
+			retiredAt := retiredAt
+			key.RetiredAt = &retiredAt
+		} else if key.ID != c.ActiveKeyID && containsKeyID(c.UpcomingKeys, key.ID) {
+			key.Upcoming = true
+		} else if key.ID != c.ActiveKeyID {
+			return fmt.Errorf("signing key %q in %s is not JWT_ACTIVE_KEY_ID and not listed in JWT_UPCOMING_KEYS or JWT_RETIRED_KEYS", key.ID, c.KeysDir)
+		}
+		keys = append(keys, *key)
+	}
+
+	var active *SigningKey
+	for i := range keys {
+		if keys[i].ID == c.ActiveKeyID {
+			active = &keys[i]
+		}
+	}
+	if active == nil {
+		return fmt.Errorf("active signing key %q not found in %s", c.ActiveKeyID, c.KeysDir)
+	}
+	if active.Private == nil {
+		return fmt.Errorf("active signing key %q has no private key", c.ActiveKeyID)
+	}
+	if active.RetiredAt != nil {
+		return fmt.Errorf("active signing key %q is listed in JWT_RETIRED_KEYS", c.ActiveKeyID)
+	}
+
+	c.SigningKeys = keys
+	return nil
+}
+
+func containsKeyID(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifiesUntil reports until when tokens signed with the key are accepted.
+// The zero time means the key has not been retired.
+func (k SigningKey) VerifiesUntil(gracePeriod time.Duration) time.Time {
+	if k.RetiredAt == nil {
+		return time.Time{}
+	}
+	return k.RetiredAt.Add(gracePeriod)
+}
+
+func readSigningKey(file string) (*SigningKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	key := &SigningKey{ID: strings.TrimSuffix(filepath.Base(file), ".pem")}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key")
+		}
+		key.Private = signer
+		key.Public = signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Private = parsed
+		key.Public = parsed.Public()
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.Public = parsed
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	switch public := key.Public.(type) {
+	case *rsa.PublicKey:
+		if public.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+	case ed25519.PublicKey:
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	return key, nil
+}
+
+// parseRetiredKeys reads "kid=RFC3339 time" pairs separated by commas.
+func parseRetiredKeys(s string) map[string]time.Time {
+	retired := make(map[string]time.Time)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, at, ok := strings.Cut(entry, "=")
+		if !ok {
+			log.Printf("Error parsing retired key %s: expected kid=time", entry)
+			continue
+		}
+		retiredAt, err := time.Parse(time.RFC3339, strings.TrimSpace(at))
+		if err != nil {
+			log.Printf("Error parsing retired key %s: %v", entry, err)
+			continue
+		}
+		retired[strings.TrimSpace(kid)] = retiredAt
+	}
+	return retired
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// superAdminRole and projectStatuses mirror models.RoleSuperAdmin and the
+// models.ProjectStatus values, so that config does not depend on internal
+// packages.
+const superAdminRole = "super_admin"
+
+var projectStatuses = []string{"not_yet_started", "progress", "completed"}
+
+// Validate refuses settings that cannot work, and settings that are unsafe to
+// run with in release mode.
+func (c *Config) Validate() error {
+	switch c.PasswordHash.Algorithm {
+	case "argon2id":
+		if c.PasswordHash.Argon2Memory == 0 || c.PasswordHash.Argon2Iterations == 0 || c.PasswordHash.Argon2Parallelism == 0 {
+			return errors.New("ARGON2_MEMORY_KIB, ARGON2_ITERATIONS and ARGON2_PARALLELISM must be positive")
+		}
+	case "bcrypt":
+		if c.PasswordHash.BcryptCost < 4 || c.PasswordHash.BcryptCost > 31 {
+			return errors.New("BCRYPT_COST must be between 4 and 31")
+		}
+	default:
+		return fmt.Errorf("unsupported PASSWORD_HASH_ALGORITHM %q", c.PasswordHash.Algorithm)
+	}
+
+	if c.Project.DeletedRetention < 0 {
+		return errors.New("PROJECT_DELETED_RETENTION must be a duration such as 30d, or 0 to keep deleted projects")
+	}
+	if c.Project.PurgeInterval <= 0 {
+		return errors.New("PROJECT_PURGE_INTERVAL must be a positive duration such as 1h")
+	}
+	if err := validateTransitions(c.Project.StatusTransitions); err != nil {
+		return err
+	}
+
+	// Self-registered accounts must never land in the platform operator role
+	if c.OIDC.IssuerURL != "" && c.OIDC.AutoProvision {
+		if c.OIDC.ProvisionRole == "" || c.OIDC.ProvisionRole == superAdminRole {
+			return fmt.Errorf("OIDC_PROVISION_ROLE %q cannot be used for provisioned accounts", c.OIDC.ProvisionRole)
+		}
+	}
+
+	if c.Server.Mode != "release" {
+		return nil
+	}
+
+	if c.JWT.Secret == DefaultJWTSecret || c.JWT.Secret == exampleJWTSecret {
+		return errors.New("JWT_SECRET must be changed from its default in release mode")
+	}
+	// The log and file drivers write invite, reset and email change links in
+	// plain text where anyone who can read them could use them
+	if c.Mail.Driver != "smtp" {
+		return fmt.Errorf("MAIL_DRIVER %q cannot be used in release mode; use smtp", c.Mail.Driver)
+	}
+	return nil
+}
+
+// validateTransitions refuses transitions between unknown statuses, so that a
+// typo cannot silently take a transition away.
+func validateTransitions(transitions map[string][]string) error {
+	known := make(map[string]bool, len(projectStatuses))
+	for _, status := range projectStatuses {
+		known[status] = true
+	}
+	for from, targets := range transitions {
+		for _, to := range targets {
+			if !known[from] || !known[to] || from == to {
+				return fmt.Errorf("PROJECT_STATUS_TRANSITIONS has an invalid transition %q; expected from:to between not_yet_started, progress and completed", from+":"+to)
+			}
+		}
+	}
+	return nil
+}
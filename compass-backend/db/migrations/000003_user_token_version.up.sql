@@ -0,0 +1,2 @@
+-- Per-user token version, bumped to invalidate every outstanding token
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+-- Create user_invites table
+CREATE TABLE IF NOT EXISTS user_invites (
+    invite_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_invites_user FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    CONSTRAINT fk_user_invites_creator FOREIGN KEY (created_by) REFERENCES users(user_id)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_user_invites_user_id ON user_invites(user_id);
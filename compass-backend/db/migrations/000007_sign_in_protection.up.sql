@@ -0,0 +1,20 @@
+-- Failed sign-in tracking for account lockout
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_login_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
+
+-- Create sign_in_attempts table
+CREATE TABLE IF NOT EXISTS sign_in_attempts (
+    attempt_id BIGSERIAL PRIMARY KEY,
+    email VARCHAR(150) NOT NULL,
+    user_id BIGINT,
+    ip_address VARCHAR(45) NOT NULL,
+    user_agent TEXT,
+    outcome VARCHAR(30) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_sign_in_attempts_user FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_sign_in_attempts_ip_created ON sign_in_attempts(ip_address, created_at);
+CREATE INDEX IF NOT EXISTS idx_sign_in_attempts_email_created ON sign_in_attempts(email, created_at);
+CREATE INDEX IF NOT EXISTS idx_sign_in_attempts_user_id ON sign_in_attempts(user_id);
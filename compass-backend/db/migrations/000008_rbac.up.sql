@@ -0,0 +1,65 @@
+-- Create roles table
+CREATE TABLE IF NOT EXISTS roles (
+    role_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description TEXT,
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create permissions table
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(100) PRIMARY KEY,
+    description TEXT
+);
+
+-- Create role_permissions table
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id BIGINT NOT NULL,
+    permission_name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role_id, permission_name),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role_id) REFERENCES roles(role_id) ON DELETE CASCADE,
+    CONSTRAINT fk_role_permissions_permission FOREIGN KEY (permission_name) REFERENCES permissions(name) ON DELETE CASCADE
+);
+
+-- Seed permissions
+INSERT INTO permissions (name, description) VALUES
+    ('user.view', 'List and view users'),
+    ('user.manage', 'Create users, change their status, reset passwords and manage invites'),
+    ('role.manage', 'Create and edit roles and assign them to users'),
+    ('settings.manage', 'View and change security settings'),
+    ('audit.view', 'View sign-in attempts'),
+    ('project.view', 'List and view projects'),
+    ('project.create', 'Create projects'),
+    ('project.update_status', 'Change project status'),
+    ('project.delete', 'Delete projects'),
+    ('spec.view', 'View project specifications'),
+    ('spec.create', 'Create project specifications'),
+    ('rfi.view', 'View project RFIs'),
+    ('rfi.create', 'Create project RFIs'),
+    ('rfi.answer', 'Answer RFIs')
+ON CONFLICT (name) DO NOTHING;
+
+-- Seed built-in roles
+INSERT INTO roles (name, description, built_in) VALUES
+    ('admin', 'Full access to every feature', TRUE),
+    ('user', 'Day-to-day project work', TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT r.role_id, p.name FROM roles r CROSS JOIN permissions p WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT r.role_id, p.name FROM roles r JOIN permissions p ON p.name IN (
+    'project.view', 'project.create', 'project.update_status',
+    'spec.view', 'spec.create',
+    'rfi.view', 'rfi.create', 'rfi.answer'
+) WHERE r.name = 'user'
+ON CONFLICT DO NOTHING;
+
+-- Users now reference a role by name instead of a fixed list
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ALTER COLUMN role TYPE VARCHAR(50);
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
@@ -0,0 +1,2 @@
+DELETE FROM permissions WHERE name = 'project.access_all';
+DROP TABLE IF EXISTS project_members;
@@ -0,0 +1,28 @@
+-- Create project_members table
+CREATE TABLE IF NOT EXISTS project_members (
+    project_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    member_role VARCHAR(20) NOT NULL CHECK (member_role IN ('owner', 'editor', 'viewer', 'client_viewer')),
+    added_by BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (project_id, user_id),
+    CONSTRAINT fk_project_members_project FOREIGN KEY (project_id) REFERENCES projects(project_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_project_members_user FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    CONSTRAINT fk_project_members_added_by FOREIGN KEY (added_by) REFERENCES users(user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_members_user_id ON project_members(user_id);
+
+-- Existing projects are owned by whoever created them
+INSERT INTO project_members (project_id, user_id, member_role, added_by)
+SELECT project_id, created_by, 'owner', created_by FROM projects
+ON CONFLICT DO NOTHING;
+
+-- Admins see and manage every project regardless of membership
+INSERT INTO permissions (name, description) VALUES
+    ('project.access_all', 'Access every project without being a member')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT role_id, 'project.access_all' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,27 @@
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT role_id, 'role.manage' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
+
+UPDATE users SET role = 'admin' WHERE role = 'super_admin';
+DELETE FROM roles WHERE name = 'super_admin';
+DELETE FROM permissions WHERE name IN ('org.manage', 'role.assign');
+UPDATE permissions SET description = 'Create and edit roles and assign them to users' WHERE name = 'role.manage';
+
+DROP INDEX IF EXISTS idx_sign_in_attempts_organization_id;
+ALTER TABLE sign_in_attempts DROP COLUMN IF EXISTS organization_id;
+
+DELETE FROM app_settings WHERE organization_id <> (SELECT organization_id FROM organizations WHERE slug = 'default');
+ALTER TABLE app_settings DROP CONSTRAINT IF EXISTS fk_app_settings_organization;
+ALTER TABLE app_settings DROP CONSTRAINT IF EXISTS app_settings_pkey;
+ALTER TABLE app_settings DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE app_settings ADD PRIMARY KEY (setting_key);
+
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS fk_projects_organization;
+DROP INDEX IF EXISTS idx_projects_organization_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS organization_id;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_organization;
+DROP INDEX IF EXISTS idx_users_organization_id;
+ALTER TABLE users DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS organizations;
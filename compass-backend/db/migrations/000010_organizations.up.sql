@@ -0,0 +1,69 @@
+-- Create organizations table
+CREATE TABLE IF NOT EXISTS organizations (
+    organization_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Everything that existed before tenancy belongs to the default organization
+INSERT INTO organizations (name, slug) VALUES ('Default', 'default')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Users belong to an organization; platform super-admins have none
+ALTER TABLE users ADD COLUMN IF NOT EXISTS organization_id BIGINT;
+UPDATE users SET organization_id = (SELECT organization_id FROM organizations WHERE slug = 'default')
+WHERE organization_id IS NULL;
+ALTER TABLE users ADD CONSTRAINT fk_users_organization FOREIGN KEY (organization_id) REFERENCES organizations(organization_id);
+CREATE INDEX IF NOT EXISTS idx_users_organization_id ON users(organization_id);
+
+-- Projects belong to their creator's organization
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS organization_id BIGINT;
+UPDATE projects p SET organization_id = u.organization_id FROM users u
+WHERE p.created_by = u.user_id AND p.organization_id IS NULL;
+UPDATE projects SET organization_id = (SELECT organization_id FROM organizations WHERE slug = 'default')
+WHERE organization_id IS NULL;
+ALTER TABLE projects ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE projects ADD CONSTRAINT fk_projects_organization FOREIGN KEY (organization_id) REFERENCES organizations(organization_id);
+CREATE INDEX IF NOT EXISTS idx_projects_organization_id ON projects(organization_id);
+
+-- Security settings are kept per organization
+ALTER TABLE app_settings ADD COLUMN IF NOT EXISTS organization_id BIGINT;
+UPDATE app_settings SET organization_id = (SELECT organization_id FROM organizations WHERE slug = 'default')
+WHERE organization_id IS NULL;
+ALTER TABLE app_settings ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE app_settings DROP CONSTRAINT IF EXISTS app_settings_pkey;
+ALTER TABLE app_settings ADD PRIMARY KEY (organization_id, setting_key);
+ALTER TABLE app_settings ADD CONSTRAINT fk_app_settings_organization FOREIGN KEY (organization_id) REFERENCES organizations(organization_id) ON DELETE CASCADE;
+
+-- Sign-in attempts are audited per organization when the account is known
+ALTER TABLE sign_in_attempts ADD COLUMN IF NOT EXISTS organization_id BIGINT;
+UPDATE sign_in_attempts a SET organization_id = u.organization_id FROM users u
+WHERE a.user_id = u.user_id AND a.organization_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_sign_in_attempts_organization_id ON sign_in_attempts(organization_id, created_at);
+
+-- Roles are shared by every organization, so only super-admins may define
+-- them; organization admins can still assign them
+INSERT INTO permissions (name, description) VALUES
+    ('org.manage', 'Create organizations and their first administrators'),
+    ('role.assign', 'Assign roles to users')
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE permissions SET description = 'Create and edit roles' WHERE name = 'role.manage';
+
+INSERT INTO roles (name, description, built_in) VALUES
+    ('super_admin', 'Platform administration across organizations', TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT r.role_id, p.name FROM roles r JOIN permissions p ON p.name IN ('org.manage', 'role.manage', 'role.assign')
+WHERE r.name = 'super_admin'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT role_id, 'role.assign' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
+
+DELETE FROM role_permissions
+WHERE permission_name = 'role.manage' AND role_id = (SELECT role_id FROM roles WHERE name = 'admin');
@@ -0,0 +1,40 @@
+-- Service accounts are users that only ever authenticate with API keys
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_service_account BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create api_keys table
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id BIGSERIAL PRIMARY KEY,
+    organization_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(150) NOT NULL,
+    prefix VARCHAR(16) NOT NULL UNIQUE,
+    secret_hash VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_by BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_api_keys_organization FOREIGN KEY (organization_id) REFERENCES organizations(organization_id) ON DELETE CASCADE,
+    CONSTRAINT fk_api_keys_user FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    CONSTRAINT fk_api_keys_creator FOREIGN KEY (created_by) REFERENCES users(user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+
+-- Create api_key_scopes table; a key can never do more than its service
+-- account's role allows
+CREATE TABLE IF NOT EXISTS api_key_scopes (
+    key_id BIGINT NOT NULL,
+    permission_name VARCHAR(100) NOT NULL,
+    PRIMARY KEY (key_id, permission_name),
+    CONSTRAINT fk_api_key_scopes_key FOREIGN KEY (key_id) REFERENCES api_keys(key_id) ON DELETE CASCADE,
+    CONSTRAINT fk_api_key_scopes_permission FOREIGN KEY (permission_name) REFERENCES permissions(name) ON DELETE CASCADE
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('api_key.manage', 'Create service accounts and issue or revoke their API keys')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT role_id, 'api_key.manage' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'user.impersonate';
+DROP TABLE IF EXISTS impersonation_requests;
+DROP TABLE IF EXISTS impersonations;
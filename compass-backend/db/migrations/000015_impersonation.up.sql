@@ -0,0 +1,41 @@
+-- Create impersonations table; one row per impersonation token issued
+CREATE TABLE IF NOT EXISTS impersonations (
+    impersonation_id BIGSERIAL PRIMARY KEY,
+    organization_id BIGINT NOT NULL,
+    actor_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    reason TEXT NOT NULL,
+    session_id VARCHAR(64) NOT NULL,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_impersonations_organization FOREIGN KEY (organization_id) REFERENCES organizations(organization_id) ON DELETE CASCADE,
+    CONSTRAINT fk_impersonations_actor FOREIGN KEY (actor_id) REFERENCES users(user_id) ON DELETE CASCADE,
+    CONSTRAINT fk_impersonations_user FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonations_organization_id ON impersonations(organization_id, created_at DESC);
+
+-- Create impersonation_requests table; every request made with an
+-- impersonation token
+CREATE TABLE IF NOT EXISTS impersonation_requests (
+    request_id BIGSERIAL PRIMARY KEY,
+    impersonation_id BIGINT NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(500) NOT NULL,
+    status_code INT NOT NULL,
+    ip_address VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_impersonation_requests_impersonation FOREIGN KEY (impersonation_id) REFERENCES impersonations(impersonation_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_requests_impersonation_id ON impersonation_requests(impersonation_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('user.impersonate', 'Act as another user of the organization for support')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT role_id, 'user.impersonate' FROM roles WHERE name = 'admin'
+ON CONFLICT DO NOTHING;
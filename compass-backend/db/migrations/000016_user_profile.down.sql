@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS email_change_requests;
+DROP TABLE IF EXISTS user_avatars;
+ALTER TABLE users DROP COLUMN IF EXISTS avatar_updated_at;
+ALTER TABLE users DROP COLUMN IF EXISTS notify_weekly_digest;
+ALTER TABLE users DROP COLUMN IF EXISTS notify_project_updates;
+ALTER TABLE users DROP COLUMN IF EXISTS notify_rfi_updates;
+ALTER TABLE users DROP COLUMN IF EXISTS timezone;
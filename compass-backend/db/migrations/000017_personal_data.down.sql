@@ -0,0 +1,2 @@
+DELETE FROM permissions WHERE name IN ('user.export', 'user.erase');
+ALTER TABLE users DROP COLUMN IF EXISTS erased_at;
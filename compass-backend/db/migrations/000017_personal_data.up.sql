@@ -0,0 +1,13 @@
+-- Mark users whose personal data has been erased; the row itself is kept so
+-- that projects, specifications and RFIs still point at it
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMP WITH TIME ZONE;
+
+INSERT INTO permissions (name, description) VALUES
+    ('user.export', 'Export all personal data held about a user'),
+    ('user.erase', 'Erase a user''s personal data')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT r.role_id, p.name FROM roles r JOIN permissions p ON p.name IN ('user.export', 'user.erase')
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DELETE FROM permissions WHERE name = 'project.update';
+ALTER TABLE projects DROP COLUMN IF EXISTS version;
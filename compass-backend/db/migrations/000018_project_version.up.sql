@@ -0,0 +1,12 @@
+-- Every change to a project bumps its version, which clients send back in
+-- If-Match so that concurrent edits do not overwrite each other
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+INSERT INTO permissions (name, description) VALUES
+    ('project.update', 'Edit project details')
+ON CONFLICT (name) DO NOTHING;
+
+-- Whoever could change a project's status may also edit its details
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT role_id, 'project.update' FROM role_permissions WHERE permission_name = 'project.update_status'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,15 @@
+-- Create project_status_history table; one row per status change
+CREATE TABLE IF NOT EXISTS project_status_history (
+    history_id BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL,
+    from_status VARCHAR(20) NOT NULL CHECK (from_status IN ('not_yet_started', 'progress', 'completed')),
+    to_status VARCHAR(20) NOT NULL CHECK (to_status IN ('not_yet_started', 'progress', 'completed')),
+    reason TEXT,
+    changed_by BIGINT NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_project_status_history_project FOREIGN KEY (project_id) REFERENCES projects(project_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT fk_project_status_history_changed_by FOREIGN KEY (changed_by) REFERENCES users(user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_status_history_project_id ON project_status_history(project_id, changed_at);
+CREATE INDEX IF NOT EXISTS idx_project_status_history_changed_by ON project_status_history(changed_by);
@@ -0,0 +1,8 @@
+DELETE FROM permissions WHERE name = 'project.restore';
+DROP INDEX IF EXISTS idx_projects_deleted_at;
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS fk_projects_archived_by;
+ALTER TABLE projects DROP COLUMN IF EXISTS archived_by;
+ALTER TABLE projects DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS fk_projects_deleted_by;
+ALTER TABLE projects DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE projects DROP COLUMN IF EXISTS deleted_at;
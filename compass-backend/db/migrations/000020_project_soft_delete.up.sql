@@ -0,0 +1,21 @@
+-- Deleted projects are kept, with their specifications and RFIs, until the
+-- retention period has passed
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS deleted_by BIGINT;
+ALTER TABLE projects ADD CONSTRAINT fk_projects_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(user_id);
+
+-- Archived projects are hidden from project listings unless asked for
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS archived_by BIGINT;
+ALTER TABLE projects ADD CONSTRAINT fk_projects_archived_by FOREIGN KEY (archived_by) REFERENCES users(user_id);
+
+CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects(deleted_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('project.restore', 'List and restore deleted projects')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role_id, permission_name)
+SELECT r.role_id, p.name FROM roles r JOIN permissions p ON p.name = 'project.restore'
+WHERE r.name = 'admin'
+ON CONFLICT DO NOTHING;
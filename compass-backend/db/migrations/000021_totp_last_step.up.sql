@@ -0,0 +1,3 @@
+-- The time step of the last TOTP code accepted for a user; codes of that step
+-- or an earlier one are refused so that an observed code cannot be replayed
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT;
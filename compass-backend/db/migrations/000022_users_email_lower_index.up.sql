@@ -0,0 +1,11 @@
+-- Email addresses are matched case-insensitively, so they must also be unique
+-- regardless of case. Addresses that differ only in case have to be merged or
+-- renamed by hand before this can run.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM users GROUP BY LOWER(email) HAVING COUNT(*) > 1) THEN
+        RAISE EXCEPTION 'users.email has addresses that differ only in case; resolve them before migrating (SELECT LOWER(email) FROM users GROUP BY 1 HAVING COUNT(*) > 1)';
+    END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users(LOWER(email));
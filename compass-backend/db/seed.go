@@ -1,7 +1,9 @@
 package db
 
 import (
+	"errors"
 	"log"
+	"time"
 
 	"compass-backend/config"
 	"compass-backend/internal/models"
@@ -10,6 +12,10 @@ import (
 	"gorm.io/gorm"
 )
 
+// DefaultOrganizationSlug identifies the organization that pre-tenancy data
+// was migrated into.
+const DefaultOrganizationSlug = "default"
+
 func SeedAdminUser(db *gorm.DB, cfg *config.Config) error {
 	// Check if admin already exists
 	var count int64
@@ -21,17 +27,26 @@ func SeedAdminUser(db *gorm.DB, cfg *config.Config) error {
 	}
 
 	// Create admin user
-	hashedPassword, err := utils.HashPassword(cfg.Admin.Password)
+	hashedPassword, err := utils.HashPassword(cfg.Admin.Password, cfg)
 	if err != nil {
 		return err
 	}
 
+	// The admin belongs to the default organization created by migration
+	var org models.Organization
+	if err := db.Where("slug = ?", DefaultOrganizationSlug).First(&org).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
 	admin := &models.User{
-		FullName:      cfg.Admin.Name,
-		Email:         cfg.Admin.Email,
-		PasswordHash:  &hashedPassword,
-		Role:          models.RoleAdmin,
-		AccountStatus: models.StatusActive,
+		OrganizationID:    &org.OrganizationID,
+		FullName:          cfg.Admin.Name,
+		Email:             cfg.Admin.Email,
+		PasswordHash:      &hashedPassword,
+		PasswordChangedAt: &now,
+		Role:              models.RoleAdmin,
+		AccountStatus:     models.StatusActive,
 	}
 
 	if err := db.Create(admin).Error; err != nil {
@@ -40,4 +55,46 @@ func SeedAdminUser(db *gorm.DB, cfg *config.Config) error {
 
 	log.Printf("Admin user created successfully: %s", cfg.Admin.Email)
 	return nil
+}
+
+// SeedSuperAdmin creates the platform super-admin, who belongs to no
+// organization, if one is configured.
+func SeedSuperAdmin(db *gorm.DB, cfg *config.Config) error {
+	if cfg.SuperAdmin.Email == "" {
+		return nil
+	}
+
+	if cfg.SuperAdmin.Password == "" {
+		return errors.New("SUPER_ADMIN_PASSWORD must be set when SUPER_ADMIN_EMAIL is")
+	}
+
+	var count int64
+	db.Model(&models.User{}).Where("email = ?", cfg.SuperAdmin.Email).Count(&count)
+
+	if count > 0 {
+		log.Println("Super-admin user already exists")
+		return nil
+	}
+
+	hashedPassword, err := utils.HashPassword(cfg.SuperAdmin.Password, cfg)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	superAdmin := &models.User{
+		FullName:          cfg.SuperAdmin.Name,
+		Email:             cfg.SuperAdmin.Email,
+		PasswordHash:      &hashedPassword,
+		PasswordChangedAt: &now,
+		Role:              models.RoleSuperAdmin,
+		AccountStatus:     models.StatusActive,
+	}
+
+	if err := db.Create(superAdmin).Error; err != nil {
+		return err
+	}
+
+	log.Printf("Super-admin user created successfully: %s", cfg.SuperAdmin.Email)
+	return nil
 }
\ No newline at end of file
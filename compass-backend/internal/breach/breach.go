@@ -0,0 +1,91 @@
+// Package breach checks passwords against lists of passwords known from data
+// breaches without ever storing or sending them in the clear.
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// commonPasswords holds the upper-case SHA-1 hashes of widely used passwords,
+// one per line.
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// Checker reports whether a password appears in a breach list.
+type Checker interface {
+	IsBreached(password string) (bool, error)
+}
+
+type checker struct {
+	bundled map[string]bool
+	dir     string
+}
+
+// NewChecker combines the bundled list of common passwords with an optional
+// local copy of a k-anonymity range list, such as the one published by Have I
+// Been Pwned. The directory holds one "<PREFIX>.txt" file per five-character
+// hash prefix, each listing "SUFFIX:COUNT" lines.
+func NewChecker(dir string) (Checker, error) {
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, errors.New("breached password list must be a directory")
+		}
+	}
+
+	bundled := make(map[string]bool)
+	for _, line := range strings.Split(commonPasswords, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			bundled[line] = true
+		}
+	}
+
+	return &checker{bundled: bundled, dir: dir}, nil
+}
+
+func (c *checker) IsBreached(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	if c.bundled[hash] {
+		return true, nil
+	}
+	if c.dir == "" {
+		return false, nil
+	}
+
+	// Only the range file of the hash prefix is read, the same way the online
+	// API is queried
+	return c.inRange(hash[:5], hash[5:])
+}
+
+func (c *checker) inRange(prefix, suffix string) (bool, error) {
+	file, err := os.Open(filepath.Join(c.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(entry, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"compass-backend/internal/models"
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService services.APIKeyService) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: apiKeyService,
+	}
+}
+
+type CreateServiceAccountRequest struct {
+	Name string          `json:"name" binding:"required"`
+	Role models.UserRole `json:"role" binding:"required"`
+}
+
+type IssueAPIKeyRequest struct {
+	Name      string              `json:"name" binding:"required"`
+	Scopes    []models.Permission `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time          `json:"expires_at"`
+}
+
+func (c *APIKeyController) CreateServiceAccount(ctx *gin.Context) {
+	var req CreateServiceAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the admin user ID from context
+	createdBy, _ := ctx.Get("user_id")
+	createdByID := createdBy.(uint64)
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	account, err := c.apiKeyService.CreateServiceAccount(orgIDValue, req.Name, req.Role, createdByID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":         "Service account created successfully",
+		"service_account": account,
+	})
+}
+
+func (c *APIKeyController) ListServiceAccounts(ctx *gin.Context) {
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	accounts, err := c.apiKeyService.ListServiceAccounts(orgIDValue)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"service_accounts": accounts})
+}
+
+func (c *APIKeyController) IssueKey(ctx *gin.Context) {
+	accountIDStr := ctx.Param("id")
+	accountID, err := strconv.ParseUint(accountIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service account ID"})
+		return
+	}
+
+	var req IssueAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the admin user ID from context
+	createdBy, _ := ctx.Get("user_id")
+	createdByID := createdBy.(uint64)
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	issued, err := c.apiKeyService.IssueKey(orgIDValue, accountID, req.Name, req.Scopes, req.ExpiresAt, createdByID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "API key created successfully. Store the key now, it cannot be shown again",
+		"key":     issued.Key,
+		"api_key": issued.APIKey,
+	})
+}
+
+func (c *APIKeyController) ListKeys(ctx *gin.Context) {
+	accountIDStr := ctx.Param("id")
+	accountID, err := strconv.ParseUint(accountIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service account ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	keys, err := c.apiKeyService.ListKeys(orgIDValue, accountID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+func (c *APIKeyController) RevokeKey(ctx *gin.Context) {
+	accountIDStr := ctx.Param("id")
+	accountID, err := strconv.ParseUint(accountIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service account ID"})
+		return
+	}
+
+	keyIDStr := ctx.Param("key_id")
+	keyID, err := strconv.ParseUint(keyIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	err = c.apiKeyService.RevokeKey(orgIDValue, accountID, keyID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
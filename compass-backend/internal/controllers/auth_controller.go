@@ -1,8 +1,14 @@
 package controllers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
 	"compass-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -23,6 +29,21 @@ type SignInRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type RenewPasswordRequest struct {
+	PasswordToken   string `json:"password_token" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" binding:"required"`
+}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
@@ -34,22 +55,175 @@ func (c *AuthController) SignIn(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, user, err := c.authService.SignIn(req.Email, req.Password)
+	result, err := c.authService.SignIn(req.Email, req.Password, clientInfo(ctx))
+	if err != nil {
+		respondSignInError(ctx, err)
+		return
+	}
+
+	if result.PasswordChangeRequired {
+		ctx.JSON(http.StatusOK, gin.H{
+			"password_change_required": true,
+			"password_token":           result.PasswordChangeToken,
+		})
+		return
+	}
+
+	if result.MFARequired {
+		ctx.JSON(http.StatusOK, gin.H{
+			"mfa_required":            true,
+			"mfa_enrollment_required": result.MFAEnrollmentRequired,
+			"mfa_token":               result.MFAToken,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, signInResponse(result))
+}
+
+// RenewPassword replaces an expired password and continues the sign-in.
+func (c *AuthController) RenewPassword(ctx *gin.Context) {
+	var req RenewPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.NewPassword != req.ConfirmPassword {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "New password and confirm password do not match"})
+		return
+	}
+
+	result, err := c.authService.RenewPassword(req.PasswordToken, req.NewPassword, clientInfo(ctx))
+	if err != nil {
+		respondPasswordError(ctx, err, http.StatusUnauthorized)
+		return
+	}
+
+	if result.MFARequired {
+		ctx.JSON(http.StatusOK, gin.H{
+			"mfa_required":            true,
+			"mfa_enrollment_required": result.MFAEnrollmentRequired,
+			"mfa_token":               result.MFAToken,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, signInResponse(result))
+}
+
+func (c *AuthController) BeginMFAEnrollment(ctx *gin.Context) {
+	var req MFATokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := c.authService.BeginMFAEnrollment(req.MFAToken)
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
+	ctx.JSON(http.StatusOK, gin.H{"enrollment": enrollment})
+}
+
+func (c *AuthController) VerifyMFA(ctx *gin.Context) {
+	var req VerifyMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.authService.VerifyMFA(req.MFAToken, req.Code, clientInfo(ctx))
+	if err != nil {
+		respondSignInError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, signInResponse(result))
+}
+
+func (c *AuthController) ListSignInAttempts(ctx *gin.Context) {
+	// Attempts are only visible within the caller's organization
+	orgID, _ := ctx.Get("organization_id")
+
+	filter := repositories.SignInAttemptFilter{
+		OrganizationID: orgID.(uint64),
+		Email:          ctx.Query("email"),
+		IP:             ctx.Query("ip"),
+		Outcome:        models.SignInOutcome(ctx.Query("outcome")),
+	}
+
+	if userIDStr := ctx.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.ParseUint(userIDStr, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	if sinceStr := ctx.Query("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since timestamp, expected RFC 3339"})
+			return
+		}
+		filter.Since = &since
+	}
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	attempts, err := c.authService.ListSignInAttempts(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"attempts": attempts})
+}
+
+func clientInfo(ctx *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
+
+func respondSignInError(ctx *gin.Context, err error) {
+	var throttleErr *services.ThrottleError
+	if errors.As(err, &throttleErr) {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttleErr.RetryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+}
+
+func signInResponse(result *services.SignInResult) gin.H {
+	response := gin.H{
+		"access_token":  result.AccessToken,
+		"refresh_token": result.RefreshToken,
 		"user": gin.H{
-			"user_id":   user.UserID,
-			"email":     user.Email,
-			"full_name": user.FullName,
-			"role":      user.Role,
+			"user_id":   result.User.UserID,
+			"email":     result.User.Email,
+			"full_name": result.User.FullName,
+			"role":      result.User.Role,
 		},
-	})
+	}
+	if len(result.RecoveryCodes) > 0 {
+		response["recovery_codes"] = result.RecoveryCodes
+	}
+	return response
 }
 
 func (c *AuthController) RefreshToken(ctx *gin.Context) {
@@ -59,7 +233,7 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, err := c.authService.RefreshToken(req.RefreshToken)
+	accessToken, refreshToken, err := c.authService.RefreshToken(req.RefreshToken, clientInfo(ctx))
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
@@ -72,7 +246,13 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 }
 
 func (c *AuthController) Logout(ctx *gin.Context) {
-	// In a stateless JWT system, logout is typically handled client-side
-	// by removing the tokens. We can implement token blacklisting if needed.
+	// Revoke the refresh token family the access token was issued under
+	sessionID := ctx.GetString("session_id")
+
+	if err := c.authService.Logout(sessionID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
\ No newline at end of file
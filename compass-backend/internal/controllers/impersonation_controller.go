@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImpersonationController struct {
+	impersonationService services.ImpersonationService
+}
+
+func NewImpersonationController(impersonationService services.ImpersonationService) *ImpersonationController {
+	return &ImpersonationController{
+		impersonationService: impersonationService,
+	}
+}
+
+type StartImpersonationRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (c *ImpersonationController) StartImpersonation(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req StartImpersonationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	// Get user ID from context
+	actorID, _ := ctx.Get("user_id")
+	actorIDValue := actorID.(uint64)
+
+	token, err := c.impersonationService.Start(orgIDValue, actorIDValue, userID, ctx.GetString("session_id"), req.Reason, clientInfo(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrImpersonationForbidden):
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"access_token":     token.AccessToken,
+		"expires_at":       token.Impersonation.ExpiresAt,
+		"impersonation_id": token.Impersonation.ImpersonationID,
+		"user":             token.User,
+	})
+}
+
+// StopImpersonation ends the impersonation the request's token belongs to.
+// The token stops working immediately.
+func (c *ImpersonationController) StopImpersonation(ctx *gin.Context) {
+	impersonationID, impersonating := ctx.Get("impersonation_id")
+	if !impersonating {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Not impersonating a user"})
+		return
+	}
+
+	if err := c.impersonationService.Stop(impersonationID.(uint64)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Impersonation ended"})
+}
+
+func (c *ImpersonationController) ListImpersonations(ctx *gin.Context) {
+	// Impersonations are only visible within the caller's organization
+	orgID, _ := ctx.Get("organization_id")
+
+	filter := repositories.ImpersonationFilter{
+		OrganizationID: orgID.(uint64),
+	}
+
+	if actorIDStr := ctx.Query("actor_id"); actorIDStr != "" {
+		actorID, err := strconv.ParseUint(actorIDStr, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor ID"})
+			return
+		}
+		filter.ActorID = &actorID
+	}
+
+	if userIDStr := ctx.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.ParseUint(userIDStr, 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	if sinceStr := ctx.Query("since"); sinceStr != "" {
+		since, err := time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since timestamp, expected RFC 3339"})
+			return
+		}
+		filter.Since = &since
+	}
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	impersonations, err := c.impersonationService.ListImpersonations(filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"impersonations": impersonations})
+}
+
+func (c *ImpersonationController) GetImpersonation(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid impersonation ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	impersonation, err := c.impersonationService.GetImpersonation(orgIDValue, id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"impersonation": impersonation})
+}
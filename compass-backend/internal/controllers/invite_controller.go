@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InviteController struct {
+	inviteService services.InviteService
+}
+
+func NewInviteController(inviteService services.InviteService) *InviteController {
+	return &InviteController{
+		inviteService: inviteService,
+	}
+}
+
+type AcceptInviteRequest struct {
+	Token           string `json:"token" binding:"required"`
+	Password        string `json:"password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" binding:"required"`
+}
+
+func (c *InviteController) AcceptInvite(ctx *gin.Context) {
+	var req AcceptInviteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Password != req.ConfirmPassword {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Password and confirm password do not match"})
+		return
+	}
+
+	user, err := c.inviteService.AcceptInvite(req.Token, req.Password)
+	if err != nil {
+		respondPasswordError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Account activated successfully",
+		"user": gin.H{
+			"user_id":   user.UserID,
+			"email":     user.Email,
+			"full_name": user.FullName,
+			"role":      user.Role,
+			"status":    user.AccountStatus,
+		},
+	})
+}
+
+func (c *InviteController) ResendInvite(ctx *gin.Context) {
+	userIDStr := ctx.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Get the admin user ID from context
+	invitedBy, _ := ctx.Get("user_id")
+	invitedByID := invitedBy.(uint64)
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	err = c.inviteService.ResendInvite(orgIDValue, userID, invitedByID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invite sent successfully"})
+}
+
+func (c *InviteController) RevokeInvite(ctx *gin.Context) {
+	userIDStr := ctx.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	err = c.inviteService.RevokeInvite(orgIDValue, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invite revoked successfully"})
+}
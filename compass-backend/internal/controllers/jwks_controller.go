@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"net/http"
+
+	"compass-backend/config"
+	"compass-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSController struct {
+	cfg *config.Config
+}
+
+func NewJWKSController(cfg *config.Config) *JWKSController {
+	return &JWKSController{
+		cfg: cfg,
+	}
+}
+
+// GetJWKS publishes the public keys that verify Compass tokens so other
+// services can check them without sharing a secret.
+func (c *JWKSController) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, utils.PublicJWKS(c.cfg))
+}
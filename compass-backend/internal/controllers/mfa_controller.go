@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"net/http"
+
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct {
+	mfaService services.MFAService
+}
+
+func NewMFAController(mfaService services.MFAService) *MFAController {
+	return &MFAController{
+		mfaService: mfaService,
+	}
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (c *MFAController) BeginEnrollment(ctx *gin.Context) {
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	enrollment, err := c.mfaService.BeginEnrollment(userIDValue)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"enrollment": enrollment})
+}
+
+func (c *MFAController) ConfirmEnrollment(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	codes, err := c.mfaService.ConfirmEnrollment(userIDValue, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled successfully",
+		"recovery_codes": codes,
+	})
+}
+
+func (c *MFAController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	codes, err := c.mfaService.RegenerateRecoveryCodes(userIDValue, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (c *MFAController) Disable(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	err := c.mfaService.Disable(userIDValue, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled successfully"})
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"compass-backend/internal/models"
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationController struct {
+	orgService services.OrganizationService
+}
+
+func NewOrganizationController(orgService services.OrganizationService) *OrganizationController {
+	return &OrganizationController{
+		orgService: orgService,
+	}
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug" binding:"required"`
+}
+
+type CreateOrganizationAdminRequest struct {
+	FullName string `json:"full_name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password"`
+}
+
+func (c *OrganizationController) CreateOrganization(ctx *gin.Context) {
+	var req CreateOrganizationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	org := &models.Organization{
+		Name: req.Name,
+		Slug: req.Slug,
+	}
+
+	err := c.orgService.CreateOrganization(org)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":      "Organization created successfully",
+		"organization": org,
+	})
+}
+
+func (c *OrganizationController) ListOrganizations(ctx *gin.Context) {
+	orgs, err := c.orgService.ListOrganizations()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"organizations": orgs})
+}
+
+func (c *OrganizationController) GetOrganization(ctx *gin.Context) {
+	orgIDStr := ctx.Param("id")
+	orgID, err := strconv.ParseUint(orgIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	org, err := c.orgService.GetOrganization(orgID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Organization not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"organization": org})
+}
+
+func (c *OrganizationController) CreateOrganizationAdmin(ctx *gin.Context) {
+	orgIDStr := ctx.Param("id")
+	orgID, err := strconv.ParseUint(orgIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organization ID"})
+		return
+	}
+
+	var req CreateOrganizationAdminRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the super-admin user ID from context
+	createdBy, _ := ctx.Get("user_id")
+	createdByID := createdBy.(uint64)
+
+	admin := &models.User{
+		FullName: req.FullName,
+		Email:    req.Email,
+	}
+
+	err = c.orgService.CreateOrganizationAdmin(orgID, admin, req.Password, createdByID)
+	if err != nil {
+		respondPasswordError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Organization admin created successfully",
+		"user": gin.H{
+			"user_id":         admin.UserID,
+			"organization_id": orgID,
+			"email":           admin.Email,
+			"full_name":       admin.FullName,
+			"role":            admin.Role,
+			"status":          admin.AccountStatus,
+		},
+	})
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PasswordResetController struct {
+	resetService services.PasswordResetService
+}
+
+func NewPasswordResetController(resetService services.PasswordResetService) *PasswordResetController {
+	return &PasswordResetController{
+		resetService: resetService,
+	}
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type CompletePasswordResetRequest struct {
+	Token           string `json:"token" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" binding:"required"`
+}
+
+func (c *PasswordResetController) ForgotPassword(ctx *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := c.resetService.RequestReset(req.Email)
+	if errors.Is(err, services.ErrTooManyResetRequests) {
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "An error occurred processing your request"})
+		return
+	}
+
+	// Same response whether or not the email belongs to an account
+	ctx.JSON(http.StatusOK, gin.H{"message": "If an account exists for this email, a password reset link has been sent"})
+}
+
+func (c *PasswordResetController) ResetPassword(ctx *gin.Context) {
+	var req CompletePasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.NewPassword != req.ConfirmPassword {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "New password and confirm password do not match"})
+		return
+	}
+
+	err := c.resetService.ResetPassword(req.Token, req.NewPassword)
+	if err != nil {
+		respondPasswordError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PersonalDataController struct {
+	personalDataService services.PersonalDataService
+}
+
+func NewPersonalDataController(personalDataService services.PersonalDataService) *PersonalDataController {
+	return &PersonalDataController{
+		personalDataService: personalDataService,
+	}
+}
+
+type EraseUserRequest struct {
+	ConfirmEmail string `json:"confirm_email" binding:"required"`
+}
+
+// ExportPersonalData downloads everything stored about a user as JSON, or as
+// a ZIP archive with format=zip.
+func (c *PersonalDataController) ExportPersonalData(ctx *gin.Context) {
+	userIDStr := ctx.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	format := ctx.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected json or zip"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	data, err := c.personalDataService.Export(orgIDValue, userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileName := fmt.Sprintf("user-%d-personal-data-%s.%s", userID, time.Now().UTC().Format("2006-01-02"), format)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	if format == "json" {
+		ctx.JSON(http.StatusOK, data)
+		return
+	}
+
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Status(http.StatusOK)
+	if err := services.WritePersonalDataArchive(ctx.Writer, data); err != nil {
+		// The response has started, so a failure can only cut the archive short
+		log.Printf("Failed to write personal data archive of user %d: %v", userID, err)
+	}
+}
+
+// EraseUser pseudonymises a user. Their name and email are replaced and their
+// credentials and sessions removed, while the projects, specifications and
+// RFIs they worked on keep pointing at the account.
+func (c *PersonalDataController) EraseUser(ctx *gin.Context) {
+	userIDStr := ctx.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req EraseUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	// Get the admin user ID from context
+	actorID, _ := ctx.Get("user_id")
+	actorIDValue := actorID.(uint64)
+
+	if err := c.personalDataService.Erase(orgIDValue, actorIDValue, userID, req.ConfirmEmail); err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User erased successfully"})
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"compass-backend/config"
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// avatarFormOverhead leaves room for the multipart boundaries and headers
+// around the avatar image itself.
+const avatarFormOverhead = 64 << 10
+
+type ProfileController struct {
+	profileService services.ProfileService
+	cfg            *config.Config
+}
+
+func NewProfileController(profileService services.ProfileService, cfg *config.Config) *ProfileController {
+	return &ProfileController{
+		profileService: profileService,
+		cfg:            cfg,
+	}
+}
+
+type UpdateProfileRequest struct {
+	FullName                *string                         `json:"full_name"`
+	Timezone                *string                         `json:"timezone"`
+	NotificationPreferences *NotificationPreferencesRequest `json:"notification_preferences"`
+}
+
+type NotificationPreferencesRequest struct {
+	RFIUpdates     *bool `json:"rfi_updates"`
+	ProjectUpdates *bool `json:"project_updates"`
+	WeeklyDigest   *bool `json:"weekly_digest"`
+}
+
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"new_email" binding:"required,email"`
+	CurrentPassword string `json:"current_password"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (c *ProfileController) GetProfile(ctx *gin.Context) {
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	user, err := c.profileService.GetProfile(userIDValue)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+func (c *ProfileController) UpdateProfile(ctx *gin.Context) {
+	var req UpdateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	update := services.ProfileUpdate{
+		FullName: req.FullName,
+		Timezone: req.Timezone,
+	}
+	if prefs := req.NotificationPreferences; prefs != nil {
+		update.RFIUpdates = prefs.RFIUpdates
+		update.ProjectUpdates = prefs.ProjectUpdates
+		update.WeeklyDigest = prefs.WeeklyDigest
+	}
+
+	user, err := c.profileService.UpdateProfile(userIDValue, update)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Profile updated successfully", "user": user})
+}
+
+// RequestEmailChange mails a confirmation link to the new address. The email
+// stays unchanged until the link is followed.
+func (c *ProfileController) RequestEmailChange(ctx *gin.Context) {
+	var req ChangeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	if err := c.profileService.RequestEmailChange(userIDValue, req.NewEmail, req.CurrentPassword); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "A confirmation link has been sent to the new email address"})
+}
+
+func (c *ProfileController) ConfirmEmailChange(ctx *gin.Context) {
+	var req ConfirmEmailChangeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.profileService.ConfirmEmailChange(req.Token); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email address changed successfully"})
+}
+
+// UploadAvatar replaces the current user's avatar with the image sent in the
+// "avatar" field of a multipart form.
+func (c *ProfileController) UploadAvatar(ctx *gin.Context) {
+	// Stop reading oversized uploads before they are spooled to memory or disk
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, int64(c.cfg.Profile.AvatarMaxBytes)+avatarFormOverhead)
+
+	file, err := ctx.FormFile("avatar")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Avatar image is too large"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Avatar image is required"})
+		return
+	}
+
+	image, err := file.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer image.Close()
+
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	if err := c.profileService.SetAvatar(userIDValue, image); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Avatar updated successfully"})
+}
+
+func (c *ProfileController) DeleteAvatar(ctx *gin.Context) {
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	if err := c.profileService.RemoveAvatar(userIDValue); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Avatar removed successfully"})
+}
+
+// GetAvatar serves the avatar of any user in the caller's organization.
+func (c *ProfileController) GetAvatar(ctx *gin.Context) {
+	userIDStr := ctx.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	avatar, err := c.profileService.GetAvatar(orgIDValue, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrAvatarNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Cache-Control", "private, max-age=300")
+	ctx.Header("X-Content-Type-Options", "nosniff")
+	ctx.Data(http.StatusOK, avatar.ContentType, avatar.Data)
+}
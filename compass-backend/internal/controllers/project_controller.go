@@ -1,11 +1,17 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
 	"compass-backend/internal/services"
+	"compass-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -57,8 +63,63 @@ type ProjectRFIRequest struct {
 	QuestionText string `json:"question_text" binding:"required"`
 }
 
+type UpdateProjectRequest struct {
+	ProjectName    *string             `json:"project_name"`
+	CompanyName    *string             `json:"company_name"`
+	CompanyAddress *string             `json:"company_address"`
+	ProjectType    *models.ProjectType `json:"project_type" binding:"omitempty,oneof=windows doors"`
+}
+
+type CloneProjectRequest struct {
+	ProjectName    string              `json:"project_name" binding:"required"`
+	CompanyName    *string             `json:"company_name"`
+	CompanyAddress *string             `json:"company_address"`
+	ProjectType    *models.ProjectType `json:"project_type" binding:"omitempty,oneof=windows doors"`
+	IncludeRFIs    bool                `json:"include_rfis"`
+}
+
 type UpdateProjectStatusRequest struct {
 	Status models.ProjectStatus `json:"status" binding:"required,oneof=not_yet_started progress completed"`
+	Reason string               `json:"reason"`
+}
+
+type AddProjectMemberRequest struct {
+	UserID     uint64                   `json:"user_id" binding:"required"`
+	MemberRole models.ProjectMemberRole `json:"member_role" binding:"required,oneof=owner editor viewer client_viewer"`
+}
+
+type UpdateProjectMemberRequest struct {
+	MemberRole models.ProjectMemberRole `json:"member_role" binding:"required,oneof=owner editor viewer client_viewer"`
+}
+
+// currentActor builds the service-level actor from the authenticated request.
+func currentActor(ctx *gin.Context) services.Actor {
+	actor := services.Actor{}
+	if userID, ok := ctx.Get("user_id"); ok {
+		actor.UserID = userID.(uint64)
+	}
+	if orgID, ok := ctx.Get("organization_id"); ok {
+		actor.OrganizationID = orgID.(uint64)
+	}
+	if permissions, ok := ctx.Get("user_permissions"); ok {
+		actor.Permissions = permissions.(models.PermissionSet)
+	}
+	return actor
+}
+
+// respondProjectError maps project access errors to 404/403 and everything
+// else to the given fallback status.
+func respondProjectError(ctx *gin.Context, err error, fallback int) {
+	switch {
+	case errors.Is(err, services.ErrProjectNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	case errors.Is(err, services.ErrProjectForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrProjectConflict):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(fallback, gin.H{"error": err.Error()})
+	}
 }
 
 func (c *ProjectController) CreateProject(ctx *gin.Context) {
@@ -68,16 +129,13 @@ func (c *ProjectController) CreateProject(ctx *gin.Context) {
 		return
 	}
 
-	// Get user ID from context
-	createdBy, _ := ctx.Get("user_id")
-	createdByID := createdBy.(uint64)
+	actor := currentActor(ctx)
 
 	project := &models.Project{
 		ProjectName:    req.ProjectName,
 		CompanyName:    req.CompanyName,
 		CompanyAddress: req.CompanyAddress,
 		ProjectType:    req.ProjectType,
-		CreatedBy:      createdByID,
 	}
 
 	// Prepare specifications if provided
@@ -105,7 +163,7 @@ func (c *ProjectController) CreateProject(ctx *gin.Context) {
 			RestrictorsAttachment: specReq.RestrictorsAttachment,
 			SpecialComments:       specReq.SpecialComments,
 			AttachmentURL:         specReq.AttachmentURL,
-			CreatedBy:             createdByID,
+			CreatedBy:             actor.UserID,
 		}
 		specifications = append(specifications, spec)
 	}
@@ -119,7 +177,7 @@ func (c *ProjectController) CreateProject(ctx *gin.Context) {
 		rfis = append(rfis, rfi)
 	}
 
-	err := c.projectService.CreateProjectWithDetails(project, specifications, rfis)
+	err := c.projectService.CreateProjectWithDetails(actor, project, specifications, rfis)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -139,23 +197,125 @@ func (c *ProjectController) GetProject(ctx *gin.Context) {
 		return
 	}
 
-	project, err := c.projectService.GetProject(projectID)
+	project, err := c.projectService.GetProject(currentActor(ctx), projectID)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		respondProjectError(ctx, err, http.StatusNotFound)
 		return
 	}
 
+	ctx.Header("ETag", projectETag(project))
 	ctx.JSON(http.StatusOK, gin.H{"project": project})
 }
 
+// CloneProject creates a new project from an existing one, for repeat orders
+// with near-identical specifications.
+func (c *ProjectController) CloneProject(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req CloneProjectRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	clone := services.ProjectClone{
+		ProjectName:    req.ProjectName,
+		CompanyName:    req.CompanyName,
+		CompanyAddress: req.CompanyAddress,
+		ProjectType:    req.ProjectType,
+		IncludeRFIs:    req.IncludeRFIs,
+	}
+
+	project, err := c.projectService.CloneProject(currentActor(ctx), projectID, clone)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.Header("ETag", projectETag(project))
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Project cloned successfully",
+		"project": project,
+	})
+}
+
+// UpdateProject changes a project's details. The request must carry the
+// project's ETag in If-Match; if the project changed since, it answers 409
+// with the current project instead.
+func (c *ProjectController) UpdateProject(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	ifMatch := ctx.GetHeader("If-Match")
+	if ifMatch == "" {
+		ctx.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header with the project's ETag is required"})
+		return
+	}
+	version, err := parseProjectETag(ifMatch)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req UpdateProjectRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update := services.ProjectUpdate{
+		ProjectName:    req.ProjectName,
+		CompanyName:    req.CompanyName,
+		CompanyAddress: req.CompanyAddress,
+		ProjectType:    req.ProjectType,
+	}
+
+	project, err := c.projectService.UpdateProject(currentActor(ctx), projectID, update, version)
+	if err != nil {
+		if errors.Is(err, services.ErrProjectConflict) {
+			ctx.Header("ETag", projectETag(project))
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "project": project})
+			return
+		}
+		respondProjectError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.Header("ETag", projectETag(project))
+	ctx.JSON(http.StatusOK, gin.H{"message": "Project updated successfully", "project": project})
+}
+
 func (c *ProjectController) ListProjects(ctx *gin.Context) {
-	projects, err := c.projectService.ListProjects()
+	filter, err := parseProjectFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := c.projectService.ListProjects(currentActor(ctx), filter, ctx.Query("cursor"))
 	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"projects": projects})
+	ctx.JSON(http.StatusOK, gin.H{
+		"projects":    page.Projects,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
 }
 
 func (c *ProjectController) UpdateProjectStatus(ctx *gin.Context) {
@@ -172,20 +332,16 @@ func (c *ProjectController) UpdateProjectStatus(ctx *gin.Context) {
 		return
 	}
 
-	// Get user ID from context
-	updatedBy, _ := ctx.Get("user_id")
-	updatedByID := updatedBy.(uint64)
-
-	err = c.projectService.UpdateProjectStatus(projectID, req.Status, updatedByID)
+	err = c.projectService.UpdateProjectStatus(currentActor(ctx), projectID, req.Status, req.Reason)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondProjectError(ctx, err, http.StatusBadRequest)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Project status updated successfully"})
 }
 
-func (c *ProjectController) DeleteProject(ctx *gin.Context) {
+func (c *ProjectController) GetStatusHistory(ctx *gin.Context) {
 	projectIDStr := ctx.Param("id")
 	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
 	if err != nil {
@@ -193,15 +349,285 @@ func (c *ProjectController) DeleteProject(ctx *gin.Context) {
 		return
 	}
 
-	// Get user role from context
-	userRole, _ := ctx.Get("user_role")
-	role := userRole.(models.UserRole)
+	history, err := c.projectService.ListStatusHistory(currentActor(ctx), projectID)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusInternalServerError)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"history": history})
+}
 
-	err = c.projectService.DeleteProject(projectID, role)
+func (c *ProjectController) DeleteProject(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
 	if err != nil {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	err = c.projectService.DeleteProject(currentActor(ctx), projectID)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusInternalServerError)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
-}
\ No newline at end of file
+}
+
+func (c *ProjectController) ArchiveProject(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	err = c.projectService.ArchiveProject(currentActor(ctx), projectID)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Project archived successfully"})
+}
+
+func (c *ProjectController) UnarchiveProject(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	err = c.projectService.UnarchiveProject(currentActor(ctx), projectID)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Project unarchived successfully"})
+}
+
+// ListDeletedProjects lists the organization's deleted projects that can still
+// be restored.
+func (c *ProjectController) ListDeletedProjects(ctx *gin.Context) {
+	projects, err := c.projectService.ListDeletedProjects(currentActor(ctx))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+func (c *ProjectController) RestoreProject(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	err = c.projectService.RestoreProject(currentActor(ctx), projectID)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusInternalServerError)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Project restored successfully"})
+}
+
+func (c *ProjectController) ListMembers(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	members, err := c.projectService.ListMembers(currentActor(ctx), projectID)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusInternalServerError)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+func (c *ProjectController) AddMember(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req AddProjectMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := c.projectService.AddMember(currentActor(ctx), projectID, req.UserID, req.MemberRole)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Member added successfully",
+		"member":  member,
+	})
+}
+
+func (c *ProjectController) UpdateMember(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	userIDStr := ctx.Param("user_id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req UpdateProjectMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = c.projectService.UpdateMemberRole(currentActor(ctx), projectID, userID, req.MemberRole)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Member updated successfully"})
+}
+
+func (c *ProjectController) RemoveMember(ctx *gin.Context) {
+	projectIDStr := ctx.Param("id")
+	projectID, err := strconv.ParseUint(projectIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	userIDStr := ctx.Param("user_id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	err = c.projectService.RemoveMember(currentActor(ctx), projectID, userID)
+	if err != nil {
+		respondProjectError(ctx, err, http.StatusBadRequest)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// projectETag identifies the version of a project a client has seen.
+func projectETag(project *models.Project) string {
+	return fmt.Sprintf("\"%d\"", project.Version)
+}
+
+// parseProjectETag returns the project version named by an If-Match header.
+func parseProjectETag(value string) (int, error) {
+	value = strings.Trim(strings.TrimPrefix(strings.TrimSpace(value), "W/"), "\"")
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid If-Match header, expected the project's ETag")
+	}
+	return version, nil
+}
+
+// parseProjectFilter reads the project list filters from the query string.
+// Date ranges include their start and exclude their end.
+func parseProjectFilter(ctx *gin.Context) (repositories.ProjectFilter, error) {
+	filter := repositories.ProjectFilter{
+		Company:    ctx.Query("company"),
+		Search:     ctx.Query("q"),
+		Sort:       repositories.ProjectSortCreatedAt,
+		Descending: true,
+	}
+
+	for _, status := range splitQueryList(ctx, "status") {
+		switch models.ProjectStatus(status) {
+		case models.StatusNotYetStarted, models.StatusProgress, models.StatusCompleted:
+			filter.Statuses = append(filter.Statuses, models.ProjectStatus(status))
+		default:
+			return filter, fmt.Errorf("invalid status %q", status)
+		}
+	}
+
+	for _, projectType := range splitQueryList(ctx, "type") {
+		switch models.ProjectType(projectType) {
+		case models.TypeWindows, models.TypeDoors:
+			filter.Types = append(filter.Types, models.ProjectType(projectType))
+		default:
+			return filter, fmt.Errorf("invalid type %q", projectType)
+		}
+	}
+
+	if createdByStr := ctx.Query("created_by"); createdByStr != "" {
+		createdBy, err := strconv.ParseUint(createdByStr, 10, 64)
+		if err != nil {
+			return filter, errors.New("invalid created_by user ID")
+		}
+		filter.CreatedBy = &createdBy
+	}
+
+	dateRanges := []struct {
+		key  string
+		dest **time.Time
+	}{
+		{"created_from", &filter.CreatedFrom},
+		{"created_to", &filter.CreatedTo},
+		{"updated_from", &filter.UpdatedFrom},
+		{"updated_to", &filter.UpdatedTo},
+	}
+	for _, r := range dateRanges {
+		if value := ctx.Query(r.key); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s timestamp, expected RFC 3339", r.key)
+			}
+			*r.dest = &t
+		}
+	}
+
+	switch archived := repositories.ProjectArchiveFilter(ctx.Query("archived")); archived {
+	case repositories.ArchivedExclude, repositories.ArchivedInclude, repositories.ArchivedOnly:
+		filter.Archived = archived
+	default:
+		return filter, fmt.Errorf("invalid archived %q, expected include or only", archived)
+	}
+
+	if sort := ctx.Query("sort"); sort != "" {
+		filter.Descending = strings.HasPrefix(sort, "-")
+		sort = strings.TrimPrefix(sort, "-")
+		filter.Sort = repositories.ProjectSort(sort)
+		if !filter.Sort.IsValid() {
+			return filter, fmt.Errorf("invalid sort %q, expected name, company, created_at or updated_at", sort)
+		}
+	}
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			return filter, errors.New("invalid limit")
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
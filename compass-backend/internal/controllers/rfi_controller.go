@@ -49,9 +49,9 @@ func (c *RFIController) CreateRFI(ctx *gin.Context) {
 		AnswerValue:  &defaultAnswer,
 	}
 
-	err = c.rfiService.CreateRFI(rfi)
+	err = c.rfiService.CreateRFI(currentActor(ctx), rfi)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondProjectError(ctx, err, http.StatusBadRequest)
 		return
 	}
 
@@ -75,13 +75,9 @@ func (c *RFIController) AnswerRFI(ctx *gin.Context) {
 		return
 	}
 
-	// Get user ID from context
-	answeredBy, _ := ctx.Get("user_id")
-	answeredByID := answeredBy.(uint64)
-
-	err = c.rfiService.AnswerRFI(rfiID, req.AnswerValue, answeredByID)
+	err = c.rfiService.AnswerRFI(currentActor(ctx), rfiID, req.AnswerValue)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondProjectError(ctx, err, http.StatusBadRequest)
 		return
 	}
 
@@ -96,9 +92,9 @@ func (c *RFIController) GetProjectRFIs(ctx *gin.Context) {
 		return
 	}
 
-	rfis, err := c.rfiService.GetProjectRFIs(projectID)
+	rfis, err := c.rfiService.GetProjectRFIs(currentActor(ctx), projectID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondProjectError(ctx, err, http.StatusInternalServerError)
 		return
 	}
 
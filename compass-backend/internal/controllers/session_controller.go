@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController struct {
+	sessionService services.SessionService
+}
+
+func NewSessionController(sessionService services.SessionService) *SessionController {
+	return &SessionController{
+		sessionService: sessionService,
+	}
+}
+
+func (c *SessionController) ListMySessions(ctx *gin.Context) {
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	sessions, err := c.sessionService.ListSessions(userIDValue, ctx.GetString("session_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+func (c *SessionController) RevokeMySession(ctx *gin.Context) {
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	if err := c.sessionService.RevokeSession(userIDValue, ctx.Param("session_id")); err != nil {
+		respondSessionError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeAllMySessions signs the current user out everywhere, including this
+// session.
+func (c *SessionController) RevokeAllMySessions(ctx *gin.Context) {
+	// Get user ID from context
+	userID, _ := ctx.Get("user_id")
+	userIDValue := userID.(uint64)
+
+	if err := c.sessionService.RevokeAllSessions(userIDValue); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Signed out of all sessions"})
+}
+
+func (c *SessionController) ListUserSessions(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	sessions, err := c.sessionService.ListUserSessions(orgIDValue, userID)
+	if err != nil {
+		respondSessionError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+func (c *SessionController) RevokeUserSession(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	if err := c.sessionService.RevokeUserSession(orgIDValue, userID, ctx.Param("session_id")); err != nil {
+		respondSessionError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
+
+// RevokeAllUserSessions terminates every session of a user, e.g. after their
+// account was compromised.
+func (c *SessionController) RevokeAllUserSessions(ctx *gin.Context) {
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	if err := c.sessionService.RevokeAllUserSessions(orgIDValue, userID); err != nil {
+		respondSessionError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "All sessions of the user were revoked"})
+}
+
+func respondSessionError(ctx *gin.Context, err error) {
+	if errors.Is(err, services.ErrSessionNotFound) || errors.Is(err, services.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
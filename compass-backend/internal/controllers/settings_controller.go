@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"net/http"
+
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SettingsController struct {
+	settingsService services.SettingsService
+}
+
+func NewSettingsController(settingsService services.SettingsService) *SettingsController {
+	return &SettingsController{
+		settingsService: settingsService,
+	}
+}
+
+type UpdateSecurityPolicyRequest struct {
+	RequireAdminMFA *bool `json:"require_admin_mfa" binding:"required"`
+}
+
+func (c *SettingsController) GetSecurityPolicy(ctx *gin.Context) {
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	policy, err := c.settingsService.GetSecurityPolicy(orgIDValue)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"policy": policy})
+}
+
+func (c *SettingsController) UpdateSecurityPolicy(ctx *gin.Context) {
+	var req UpdateSecurityPolicyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the admin user ID from context
+	updatedBy, _ := ctx.Get("user_id")
+	updatedByID := updatedBy.(uint64)
+
+	policy := &services.SecurityPolicy{
+		RequireAdminMFA: *req.RequireAdminMFA,
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	err := c.settingsService.UpdateSecurityPolicy(orgIDValue, policy, updatedByID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Security policy updated successfully",
+		"policy":  policy,
+	})
+}
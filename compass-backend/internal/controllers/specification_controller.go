@@ -86,9 +86,9 @@ func (c *SpecificationController) CreateSpecification(ctx *gin.Context) {
 		CreatedBy:             createdByID,
 	}
 
-	err = c.specService.CreateSpecification(spec)
+	err = c.specService.CreateSpecification(currentActor(ctx), spec)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondProjectError(ctx, err, http.StatusBadRequest)
 		return
 	}
 
@@ -106,9 +106,9 @@ func (c *SpecificationController) GetProjectSpecifications(ctx *gin.Context) {
 		return
 	}
 
-	specs, err := c.specService.GetProjectSpecifications(projectID)
+	specs, err := c.specService.GetProjectSpecifications(currentActor(ctx), projectID)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondProjectError(ctx, err, http.StatusInternalServerError)
 		return
 	}
 
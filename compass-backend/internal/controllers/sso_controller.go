@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"compass-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SSOController struct {
+	ssoService services.SSOService
+}
+
+func NewSSOController(ssoService services.SSOService) *SSOController {
+	return &SSOController{
+		ssoService: ssoService,
+	}
+}
+
+type SSOCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+func (c *SSOController) Login(ctx *gin.Context) {
+	login, err := c.ssoService.BeginLogin(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider is unavailable"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, login)
+}
+
+func (c *SSOController) Callback(ctx *gin.Context) {
+	var req SSOCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.ssoService.CompleteLogin(ctx.Request.Context(), req.Code, req.State, clientInfo(ctx))
+	if err != nil {
+		if errors.Is(err, services.ErrSSOAccountPending) || errors.Is(err, services.ErrSSOAccountNotLinked) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		respondSignInError(ctx, err)
+		return
+	}
+
+	if result.MFARequired {
+		ctx.JSON(http.StatusOK, gin.H{
+			"mfa_required":            true,
+			"mfa_enrollment_required": result.MFAEnrollmentRequired,
+			"mfa_token":               result.MFAToken,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, signInResponse(result))
+}
@@ -1,22 +1,32 @@
 package controllers
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
 	"compass-backend/internal/services"
+	"compass-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 type UserController struct {
-	userService services.UserService
+	userService        services.UserService
+	offboardingService services.OffboardingService
 }
 
-func NewUserController(userService services.UserService) *UserController {
+func NewUserController(userService services.UserService, offboardingService services.OffboardingService) *UserController {
 	return &UserController{
-		userService: userService,
+		userService:        userService,
+		offboardingService: offboardingService,
 	}
 }
 
@@ -24,21 +34,31 @@ type CreateUserRequest struct {
 	FullName string          `json:"full_name" binding:"required"`
 	Email    string          `json:"email" binding:"required,email"`
 	Password string          `json:"password"`
-	Role     models.UserRole `json:"role" binding:"required,oneof=admin user"`
+	Role     models.UserRole `json:"role" binding:"required"`
 }
 
 type UpdateUserStatusRequest struct {
 	Status models.AccountStatus `json:"status" binding:"required,oneof=pending active disabled"`
 }
 
+type UpdateUserRequest struct {
+	FullName *string          `json:"full_name"`
+	Role     *models.UserRole `json:"role"`
+}
+
+type OffboardUserRequest struct {
+	SuccessorID uint64 `json:"successor_id" binding:"required"`
+	DryRun      bool   `json:"dry_run"`
+}
+
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
-	NewPassword     string `json:"new_password" binding:"required,min=8"`
+	NewPassword     string `json:"new_password" binding:"required"`
 	ConfirmPassword string `json:"confirm_password" binding:"required"`
 }
 
 type ResetPasswordRequest struct {
-	NewPassword string `json:"new_password" binding:"required,min=8"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 func (c *UserController) CreateUser(ctx *gin.Context) {
@@ -48,19 +68,29 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 		return
 	}
 
+	// Any role beyond the default one needs the same permission as assigning it
+	if req.Role != models.RoleUser && !requireRoleAssign(ctx) {
+		return
+	}
+
 	// Get the admin user ID from context
 	invitedBy, _ := ctx.Get("user_id")
 	invitedByID := invitedBy.(uint64)
 
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
 	user := &models.User{
-		FullName: req.FullName,
-		Email:    req.Email,
-		Role:     req.Role,
+		OrganizationID: &orgIDValue,
+		FullName:       req.FullName,
+		Email:          req.Email,
+		Role:           req.Role,
 	}
 
 	err := c.userService.CreateUser(user, req.Password, invitedByID)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondPasswordError(ctx, err, http.StatusBadRequest)
 		return
 	}
 
@@ -90,9 +120,13 @@ func (c *UserController) UpdateUserStatus(ctx *gin.Context) {
 		return
 	}
 
-	err = c.userService.UpdateUserStatus(userID, req.Status)
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	err = c.userService.UpdateUserStatus(orgIDValue, userID, req.Status)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondUserError(ctx, err)
 		return
 	}
 
@@ -100,13 +134,174 @@ func (c *UserController) UpdateUserStatus(ctx *gin.Context) {
 }
 
 func (c *UserController) ListUsers(ctx *gin.Context) {
-	users, err := c.userService.ListUsers()
+	filter, err := parseUserFilter(ctx)
 	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		filter.Limit = limit
+	}
+
+	page, err := c.userService.ListUsers(filter, ctx.Query("cursor"))
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidCursor) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"users": users})
+	ctx.JSON(http.StatusOK, gin.H{
+		"users":       page.Users,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// ExportUsers downloads every user matching the list filters as CSV, for
+// access reviews.
+func (c *UserController) ExportUsers(ctx *gin.Context) {
+	filter, err := parseUserFilter(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users-%s.csv"`, time.Now().UTC().Format("2006-01-02")))
+
+	w := csv.NewWriter(ctx.Writer)
+	w.Write([]string{"user_id", "full_name", "email", "role", "account_status", "mfa_enabled", "service_account", "invited_by", "locked_until", "password_changed_at", "created_at"})
+
+	err = c.userService.ExportUsers(filter, func(user *models.User) error {
+		invitedBy := ""
+		if user.InvitedByUser != nil {
+			invitedBy = user.InvitedByUser.Email
+		}
+		return w.Write([]string{
+			strconv.FormatUint(user.UserID, 10),
+			csvSafe(user.FullName),
+			csvSafe(user.Email),
+			csvSafe(string(user.Role)),
+			string(user.AccountStatus),
+			strconv.FormatBool(user.MFAEnabled),
+			strconv.FormatBool(user.IsServiceAccount),
+			csvSafe(invitedBy),
+			formatOptionalTime(user.LockedUntil),
+			formatOptionalTime(user.PasswordChangedAt),
+			user.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	})
+	w.Flush()
+
+	// The response has started, so a failure can only cut the file short
+	if err == nil {
+		err = w.Error()
+	}
+	if err != nil {
+		log.Printf("Failed to export users: %v", err)
+	}
+}
+
+func (c *UserController) GetUser(ctx *gin.Context) {
+	userIDStr := ctx.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	user, err := c.userService.GetUser(orgIDValue, userID)
+	if err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+func (c *UserController) UpdateUser(ctx *gin.Context) {
+	userIDStr := ctx.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req UpdateUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Changing the role needs the same permission as assigning one
+	if req.Role != nil && !requireRoleAssign(ctx) {
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	user, err := c.userService.UpdateUser(orgIDValue, userID, services.UserUpdate{
+		FullName: req.FullName,
+		Role:     req.Role,
+	})
+	if err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User updated successfully", "user": user})
+}
+
+// OffboardUser disables a departing user and transfers their projects and
+// invited users to a successor. A dry run only reports what would move.
+func (c *UserController) OffboardUser(ctx *gin.Context) {
+	userIDStr := ctx.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req OffboardUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	// Get the admin user ID from context
+	actorID, _ := ctx.Get("user_id")
+	actorIDValue := actorID.(uint64)
+
+	plan, err := c.offboardingService.Offboard(orgIDValue, actorIDValue, userID, req.SuccessorID, req.DryRun)
+	if err != nil {
+		respondUserError(ctx, err)
+		return
+	}
+
+	message := "User offboarded successfully"
+	if req.DryRun {
+		message = "Dry run, nothing was changed"
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": message, "dry_run": req.DryRun, "summary": plan})
 }
 
 func (c *UserController) ChangePassword(ctx *gin.Context) {
@@ -128,7 +323,7 @@ func (c *UserController) ChangePassword(ctx *gin.Context) {
 
 	err := c.userService.ChangePassword(userIDValue, req.CurrentPassword, req.NewPassword)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondPasswordError(ctx, err, http.StatusBadRequest)
 		return
 	}
 
@@ -149,11 +344,142 @@ func (c *UserController) ResetPassword(ctx *gin.Context) {
 		return
 	}
 
-	err = c.userService.ResetPassword(userID, req.NewPassword)
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	err = c.userService.ResetPassword(orgIDValue, userID, req.NewPassword)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondPasswordError(ctx, err, http.StatusBadRequest)
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
-}
\ No newline at end of file
+}
+
+func (c *UserController) UnlockUser(ctx *gin.Context) {
+	userIDStr := ctx.Param("id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+	orgIDValue := orgID.(uint64)
+
+	err = c.userService.UnlockUser(orgIDValue, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
+
+// respondPasswordError lists every policy rule a rejected password breaks and
+// answers other errors with the given status.
+func respondPasswordError(ctx *gin.Context, err error, status int) {
+	var policyErr *services.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":      err.Error(),
+			"violations": policyErr.Violations,
+		})
+		return
+	}
+	ctx.JSON(status, gin.H{"error": err.Error()})
+}
+
+// parseUserFilter reads the search, filter and sort parameters shared by the
+// user listing and export. Roles and statuses accept comma-separated lists,
+// and a sort field prefixed with "-" sorts descending.
+func parseUserFilter(ctx *gin.Context) (repositories.UserFilter, error) {
+	// Get the organization ID from context
+	orgID, _ := ctx.Get("organization_id")
+
+	filter := repositories.UserFilter{
+		OrganizationID: orgID.(uint64),
+		Search:         ctx.Query("q"),
+		Sort:           repositories.UserSortName,
+	}
+
+	for _, role := range splitQueryList(ctx, "role") {
+		filter.Roles = append(filter.Roles, models.UserRole(role))
+	}
+
+	for _, status := range splitQueryList(ctx, "status") {
+		switch models.AccountStatus(status) {
+		case models.StatusPending, models.StatusActive, models.StatusDisabled:
+			filter.Statuses = append(filter.Statuses, models.AccountStatus(status))
+		default:
+			return filter, fmt.Errorf("invalid status %q", status)
+		}
+	}
+
+	if sort := ctx.Query("sort"); sort != "" {
+		if strings.HasPrefix(sort, "-") {
+			filter.Descending = true
+			sort = sort[1:]
+		}
+		filter.Sort = repositories.UserSort(sort)
+		if !filter.Sort.IsValid() {
+			return filter, fmt.Errorf("invalid sort %q, expected name, email or created_at", sort)
+		}
+	}
+
+	return filter, nil
+}
+
+// splitQueryList returns the values of a query parameter that may be repeated
+// or hold a comma-separated list.
+func splitQueryList(ctx *gin.Context, key string) []string {
+	var values []string
+	for _, param := range ctx.QueryArray(key) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// csvSafe stops spreadsheet applications from evaluating a cell that starts
+// like a formula.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// requireRoleAssign answers 403 and reports false unless the caller may
+// assign roles.
+func requireRoleAssign(ctx *gin.Context) bool {
+	permissions, _ := ctx.Get("user_permissions")
+	if granted, ok := permissions.(models.PermissionSet); !ok || !granted.Has(models.PermRoleAssign) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + string(models.PermRoleAssign)})
+		return false
+	}
+	return true
+}
+
+func respondUserError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrLastAdmin), errors.Is(err, services.ErrUserErased):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
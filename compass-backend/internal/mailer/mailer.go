@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"fmt"
+
+	"compass-backend/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing e-mail. Implementations are selected with the
+// MAIL_DRIVER setting.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "log":
+		return NewLogMailer(cfg.Mail.From), nil
+	case "file":
+		return NewFileMailer(cfg.Mail.From, cfg.Mail.OutputDir), nil
+	case "smtp":
+		return NewSMTPMailer(cfg.Mail.From, cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver: %s", cfg.Mail.Driver)
+	}
+}
@@ -6,13 +6,30 @@ import (
 
 	"compass-backend/config"
 	"compass-backend/internal/models"
+	"compass-backend/internal/services"
 	"compass-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+func AuthMiddleware(cfg *config.Config, authStateService services.AuthStateService, sessionService services.SessionService, apiKeyService services.APIKeyService, impersonationService services.ImpersonationService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Machine integrations authenticate with an API key instead of a
+		// bearer token
+		if apiKey := extractAPIKey(c); apiKey != "" {
+			state, key, err := apiKeyService.Authenticate(apiKey)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+
+			setAuthContext(c, state)
+			c.Set("api_key_id", key.KeyID)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
@@ -35,15 +52,89 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		// Check the token against the user's current state so that disabling,
+		// password resets and role changes take effect immediately
+		state, err := authStateService.GetState(claims.UserID)
+		if err != nil || state.TokenVersion != claims.Version || state.AccountStatus != models.StatusActive ||
+			state.OrganizationID != claims.OrganizationID {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+
+		// An impersonation token rides on the admin's session and lasts only
+		// as long as the admin may still impersonate
+		sessionUserID := claims.UserID
+		if claims.Actor != nil {
+			if err := impersonationService.Validate(claims); err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+				c.Abort()
+				return
+			}
+			sessionUserID = claims.Actor.UserID
+		}
+
+		// Signing out of a session ends its access tokens too
+		client := services.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+		if err := sessionService.ValidateSession(sessionUserID, claims.SessionID, client); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Abort()
+			return
+		}
+
 		// Set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
+		setAuthContext(c, state)
+		if claims.Actor == nil {
+			c.Set("session_id", claims.SessionID)
+			c.Next()
+			return
+		}
 
+		// The session belongs to the admin, so it is not exposed as the
+		// user's own. Every impersonated request is logged with both identities.
+		c.Set("impersonator_id", claims.Actor.UserID)
+		c.Set("impersonation_id", claims.Actor.ImpersonationID)
 		c.Next()
+		impersonationService.RecordRequest(claims.Actor.ImpersonationID, c.Request.Method, c.Request.URL.Path, c.Writer.Status(), c.ClientIP())
 	}
 }
 
+// BlockImpersonation rejects the request when it is made with an
+// impersonation token, for actions only the account owner may take.
+func BlockImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonating := c.Get("impersonator_id"); impersonating {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not allowed while impersonating a user"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// extractAPIKey returns the key sent in an X-API-Key or "Authorization: ApiKey"
+// header, if any.
+func extractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+
+	tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(tokenParts) == 2 && tokenParts[0] == "ApiKey" {
+		return tokenParts[1]
+	}
+	return ""
+}
+
+func setAuthContext(c *gin.Context, state *services.AuthState) {
+	c.Set("user_id", state.UserID)
+	c.Set("organization_id", state.OrganizationID)
+	c.Set("user_email", state.Email)
+	c.Set("user_role", state.Role)
+	c.Set("user_permissions", state.Permissions)
+}
+
 func AdminOnly() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("user_role")
@@ -62,4 +153,64 @@ func AdminOnly() gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// RequireAnyPermission allows the request through if the authenticated user
+// holds at least one of the given permissions.
+func RequireAnyPermission(permissions ...models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("user_permissions")
+		if !exists {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			c.Abort()
+			return
+		}
+
+		granted, ok := value.(models.PermissionSet)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			c.Abort()
+			return
+		}
+
+		for _, p := range permissions {
+			if granted.Has(p) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		c.Abort()
+	}
+}
+
+// RequirePermission allows the request through only if the authenticated user
+// holds every one of the given permissions.
+func RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("user_permissions")
+		if !exists {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			c.Abort()
+			return
+		}
+
+		granted, ok := value.(models.PermissionSet)
+		if !ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			c.Abort()
+			return
+		}
+
+		for _, p := range permissions {
+			if !granted.Has(p) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + string(p)})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey authenticates a service account. Only the prefix and a hash of the
+// secret are stored; the full key is shown once when it is issued.
+type APIKey struct {
+	KeyID          uint64       `gorm:"primaryKey;autoIncrement" json:"key_id"`
+	OrganizationID uint64       `gorm:"not null" json:"organization_id"`
+	UserID         uint64       `gorm:"not null;index" json:"user_id"`
+	Name           string       `gorm:"size:150;not null" json:"name"`
+	Prefix         string       `gorm:"size:16;uniqueIndex;not null" json:"prefix"`
+	SecretHash     string       `gorm:"size:64;not null" json:"-"`
+	Scopes         []Permission `gorm:"-" json:"scopes"`
+	ExpiresAt      *time.Time   `json:"expires_at,omitempty"`
+	LastUsedAt     *time.Time   `json:"last_used_at,omitempty"`
+	RevokedAt      *time.Time   `json:"revoked_at,omitempty"`
+	CreatedBy      uint64       `gorm:"not null" json:"created_by"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	k.CreatedAt = time.Now()
+	return nil
+}
+
+type APIKeyScope struct {
+	KeyID          uint64     `gorm:"primaryKey" json:"key_id"`
+	PermissionName Permission `gorm:"primaryKey;size:100" json:"permission_name"`
+}
+
+func (APIKeyScope) TableName() string {
+	return "api_key_scopes"
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Impersonation records an admin acting as another user with a short-lived
+// access token.
+type Impersonation struct {
+	ImpersonationID uint64     `gorm:"primaryKey;autoIncrement" json:"impersonation_id"`
+	OrganizationID  uint64     `gorm:"not null" json:"organization_id"`
+	ActorID         uint64     `gorm:"not null" json:"actor_id"`
+	Actor           *User      `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	UserID          uint64     `gorm:"not null" json:"user_id"`
+	User            *User      `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	Reason          string     `gorm:"type:text;not null" json:"reason"`
+	SessionID       string     `gorm:"size:64;not null" json:"-"`
+	IPAddress       string     `gorm:"size:64;not null" json:"ip_address"`
+	ExpiresAt       time.Time  `gorm:"not null" json:"expires_at"`
+	EndedAt         *time.Time `json:"ended_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+
+	Requests []ImpersonationRequest `gorm:"foreignKey:ImpersonationID" json:"requests,omitempty"`
+}
+
+func (Impersonation) TableName() string {
+	return "impersonations"
+}
+
+func (i *Impersonation) BeforeCreate(tx *gorm.DB) error {
+	i.CreatedAt = time.Now()
+	return nil
+}
+
+// ImpersonationRequest is one request made with an impersonation token.
+type ImpersonationRequest struct {
+	RequestID       uint64    `gorm:"primaryKey;autoIncrement" json:"request_id"`
+	ImpersonationID uint64    `gorm:"not null;index" json:"impersonation_id"`
+	Method          string    `gorm:"size:10;not null" json:"method"`
+	Path            string    `gorm:"size:500;not null" json:"path"`
+	StatusCode      int       `gorm:"not null" json:"status_code"`
+	IPAddress       string    `gorm:"size:64;not null" json:"ip_address"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (ImpersonationRequest) TableName() string {
+	return "impersonation_requests"
+}
+
+func (r *ImpersonationRequest) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserInvite is a single-use activation link sent to a pending user. Only the
+// SHA-256 hash of the token is stored.
+type UserInvite struct {
+	InviteID   uint64     `gorm:"primaryKey;autoIncrement" json:"invite_id"`
+	UserID     uint64     `gorm:"not null;index" json:"user_id"`
+	User       *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	TokenHash  string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy  uint64     `gorm:"not null" json:"created_by"`
+	Creator    *User      `gorm:"foreignKey:CreatedBy" json:"creator,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (UserInvite) TableName() string {
+	return "user_invites"
+}
+
+func (ui *UserInvite) BeforeCreate(tx *gorm.DB) error {
+	ui.CreatedAt = time.Now()
+	return nil
+}
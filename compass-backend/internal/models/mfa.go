@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode is a single-use MFA backup code. Only the SHA-256 hash of the
+// code is stored.
+type RecoveryCode struct {
+	CodeID    uint64     `gorm:"primaryKey;autoIncrement" json:"code_id"`
+	UserID    uint64     `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (RecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+func (rc *RecoveryCode) BeforeCreate(tx *gorm.DB) error {
+	rc.CreatedAt = time.Now()
+	return nil
+}
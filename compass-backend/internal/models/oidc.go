@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OIDCAuthRequest is an SSO login that was started but not yet completed.
+// Only the SHA-256 hash of the state parameter is stored.
+type OIDCAuthRequest struct {
+	RequestID    uint64     `gorm:"primaryKey;autoIncrement" json:"request_id"`
+	StateHash    string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Nonce        string     `gorm:"size:100;not null" json:"-"`
+	CodeVerifier string     `gorm:"size:100;not null" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (OIDCAuthRequest) TableName() string {
+	return "oidc_auth_requests"
+}
+
+func (r *OIDCAuthRequest) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	return nil
+}
+
+// UserIdentity links a user to their account at an external identity
+// provider, identified by the issuer and subject of its ID tokens.
+type UserIdentity struct {
+	IdentityID  uint64     `gorm:"primaryKey;autoIncrement" json:"identity_id"`
+	UserID      uint64     `gorm:"not null;index" json:"user_id"`
+	User        *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	Issuer      string     `gorm:"size:255;not null" json:"issuer"`
+	Subject     string     `gorm:"size:255;not null" json:"subject"`
+	Email       string     `gorm:"size:150;not null" json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
+
+func (ui *UserIdentity) BeforeCreate(tx *gorm.DB) error {
+	ui.CreatedAt = time.Now()
+	return nil
+}
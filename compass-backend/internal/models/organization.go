@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Organization is a tenant. Users, projects and their specifications and RFIs
+// are only ever visible inside the organization they belong to.
+type Organization struct {
+	OrganizationID uint64    `gorm:"primaryKey;autoIncrement" json:"organization_id"`
+	Name           string    `gorm:"size:200;not null" json:"name"`
+	Slug           string    `gorm:"size:100;uniqueIndex;not null" json:"slug"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+func (o *Organization) BeforeUpdate(tx *gorm.DB) error {
+	o.UpdatedAt = time.Now()
+	return nil
+}
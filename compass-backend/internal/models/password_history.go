@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordHistory keeps the hash of a password a user has used before.
+type PasswordHistory struct {
+	HistoryID    uint64    `gorm:"primaryKey;autoIncrement" json:"history_id"`
+	UserID       uint64    `gorm:"not null;index" json:"user_id"`
+	PasswordHash string    `gorm:"size:255;not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
+
+func (h *PasswordHistory) BeforeCreate(tx *gorm.DB) error {
+	h.CreatedAt = time.Now()
+	return nil
+}
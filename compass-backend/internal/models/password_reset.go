@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetToken is a single-use, time-limited token issued by the
+// forgotten-password flow. Only the SHA-256 hash of the token is stored.
+type PasswordResetToken struct {
+	ResetID   uint64     `gorm:"primaryKey;autoIncrement" json:"reset_id"`
+	UserID    uint64     `gorm:"not null;index" json:"user_id"`
+	User      *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"user,omitempty"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+func (prt *PasswordResetToken) BeforeCreate(tx *gorm.DB) error {
+	prt.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,50 @@
+package models
+
+type Permission string
+
+const (
+	PermUserView        Permission = "user.view"
+	PermUserManage      Permission = "user.manage"
+	PermOrgManage       Permission = "org.manage"
+	PermRoleManage      Permission = "role.manage"
+	PermRoleAssign      Permission = "role.assign"
+	PermSettingsManage  Permission = "settings.manage"
+	PermAuditView       Permission = "audit.view"
+	PermAPIKeyManage    Permission = "api_key.manage"
+	PermUserImpersonate Permission = "user.impersonate"
+	PermUserExport      Permission = "user.export"
+	PermUserErase       Permission = "user.erase"
+
+	PermProjectView         Permission = "project.view"
+	PermProjectCreate       Permission = "project.create"
+	PermProjectUpdate       Permission = "project.update"
+	PermProjectUpdateStatus Permission = "project.update_status"
+	PermProjectDelete       Permission = "project.delete"
+	PermProjectRestore      Permission = "project.restore"
+	PermProjectAccessAll    Permission = "project.access_all"
+
+	PermSpecView   Permission = "spec.view"
+	PermSpecCreate Permission = "spec.create"
+
+	PermRFIView   Permission = "rfi.view"
+	PermRFICreate Permission = "rfi.create"
+	PermRFIAnswer Permission = "rfi.answer"
+)
+
+// PermissionDefinition is a permission known to the system, as stored in the
+// permissions table.
+type PermissionDefinition struct {
+	Name        Permission `gorm:"primaryKey;size:100" json:"name"`
+	Description string     `gorm:"type:text" json:"description"`
+}
+
+func (PermissionDefinition) TableName() string {
+	return "permissions"
+}
+
+// PermissionSet is the resolved set of permissions held by a user.
+type PermissionSet map[Permission]bool
+
+func (ps PermissionSet) Has(p Permission) bool {
+	return ps[p]
+}
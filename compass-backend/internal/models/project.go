@@ -20,6 +20,7 @@ const (
 
 type Project struct {
 	ProjectID      uint64                  `gorm:"primaryKey;autoIncrement" json:"project_id"`
+	OrganizationID uint64                  `gorm:"not null;index" json:"organization_id"`
 	ProjectName    string                  `gorm:"size:200;not null" json:"project_name"`
 	CompanyName    string                  `gorm:"size:200" json:"company_name"`
 	CompanyAddress string                  `gorm:"type:text" json:"company_address"`
@@ -33,6 +34,12 @@ type Project struct {
 	RFIs           []ProjectRFI            `gorm:"foreignKey:ProjectID;references:ProjectID" json:"rfis,omitempty"`
 	CreatedAt      time.Time               `json:"created_at"`
 	UpdatedAt      time.Time               `json:"updated_at"`
+	Version        int                     `gorm:"not null;default:1" json:"version"`
+	ArchivedAt     *time.Time              `json:"archived_at,omitempty"`
+	ArchivedBy     *uint64                 `json:"archived_by,omitempty"`
+	DeletedAt      gorm.DeletedAt          `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedBy      *uint64                 `json:"deleted_by,omitempty"`
+	Deleter        *User                   `gorm:"foreignKey:DeletedBy" json:"deleter,omitempty"`
 }
 
 func (Project) TableName() string {
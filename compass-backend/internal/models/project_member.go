@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProjectMemberRole string
+
+const (
+	MemberOwner        ProjectMemberRole = "owner"
+	MemberEditor       ProjectMemberRole = "editor"
+	MemberViewer       ProjectMemberRole = "viewer"
+	MemberClientViewer ProjectMemberRole = "client_viewer"
+)
+
+type ProjectMember struct {
+	ProjectID  uint64            `gorm:"primaryKey" json:"project_id"`
+	Project    *Project          `gorm:"foreignKey:ProjectID;references:ProjectID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"project,omitempty"`
+	UserID     uint64            `gorm:"primaryKey" json:"user_id"`
+	User       *User             `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	MemberRole ProjectMemberRole `gorm:"type:varchar(20);not null;check:member_role IN ('owner','editor','viewer','client_viewer')" json:"member_role"`
+	AddedBy    *uint64           `json:"added_by,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+func (ProjectMember) TableName() string {
+	return "project_members"
+}
+
+func (pm *ProjectMember) BeforeCreate(tx *gorm.DB) error {
+	pm.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProjectStatusChange records a project moving from one status to another.
+type ProjectStatusChange struct {
+	HistoryID  uint64        `gorm:"primaryKey;autoIncrement" json:"history_id"`
+	ProjectID  uint64        `gorm:"not null;index" json:"project_id"`
+	FromStatus ProjectStatus `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus   ProjectStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	Reason     *string       `gorm:"type:text" json:"reason,omitempty"`
+	ChangedBy  uint64        `gorm:"not null" json:"changed_by"`
+	Changer    *User         `gorm:"foreignKey:ChangedBy" json:"changer,omitempty"`
+	ChangedAt  time.Time     `json:"changed_at"`
+}
+
+func (ProjectStatusChange) TableName() string {
+	return "project_status_history"
+}
+
+func (c *ProjectStatusChange) BeforeCreate(tx *gorm.DB) error {
+	c.ChangedAt = time.Now()
+	return nil
+}
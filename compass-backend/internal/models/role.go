@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Role is a named set of permissions shared by every organization. The
+// built-in super_admin, admin and user roles are seeded by migration and
+// cannot be deleted.
+type Role struct {
+	RoleID      uint64       `gorm:"primaryKey;autoIncrement" json:"role_id"`
+	Name        UserRole     `gorm:"size:50;uniqueIndex;not null" json:"name"`
+	Description string       `gorm:"type:text" json:"description"`
+	BuiltIn     bool         `gorm:"not null;default:false" json:"built_in"`
+	Permissions []Permission `gorm:"-" json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+func (r *Role) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *Role) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+type RolePermission struct {
+	RoleID         uint64     `gorm:"primaryKey" json:"role_id"`
+	PermissionName Permission `gorm:"primaryKey;size:100" json:"permission_name"`
+}
+
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Session records one sign-in. Its ID is carried in the sid claim of the
+// tokens issued for it and is shared with their refresh token family.
+type Session struct {
+	SessionID  string     `gorm:"primaryKey;size:64" json:"session_id"`
+	UserID     uint64     `gorm:"not null;index" json:"user_id"`
+	IPAddress  string     `gorm:"size:64;not null" json:"ip_address"`
+	UserAgent  string     `gorm:"size:512;not null" json:"user_agent"`
+	Device     string     `gorm:"size:100;not null" json:"device"`
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Current    bool       `gorm:"-" json:"current"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+func (s *Session) BeforeCreate(tx *gorm.DB) error {
+	s.CreatedAt = time.Now()
+	return nil
+}
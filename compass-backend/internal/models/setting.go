@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	SettingRequireAdminMFA = "require_admin_mfa"
+)
+
+// AppSetting is an admin-managed key/value policy setting of an organization.
+type AppSetting struct {
+	OrganizationID uint64    `gorm:"primaryKey" json:"organization_id"`
+	SettingKey     string    `gorm:"primaryKey;size:100" json:"setting_key"`
+	SettingValue   string    `gorm:"type:text;not null" json:"setting_value"`
+	UpdatedBy      *uint64   `json:"updated_by,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (AppSetting) TableName() string {
+	return "app_settings"
+}
+
+func (s *AppSetting) BeforeSave(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now()
+	return nil
+}
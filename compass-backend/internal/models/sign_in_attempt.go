@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SignInOutcome string
+
+const (
+	OutcomeSuccess            SignInOutcome = "success"
+	OutcomeSSOSuccess         SignInOutcome = "sso_success"
+	OutcomeMFAChallenge       SignInOutcome = "mfa_challenge"
+	OutcomeInvalidCredentials SignInOutcome = "invalid_credentials"
+	OutcomeInactive           SignInOutcome = "inactive"
+	OutcomeLocked             SignInOutcome = "locked"
+	OutcomeThrottled          SignInOutcome = "throttled"
+	OutcomeMFAFailed          SignInOutcome = "mfa_failed"
+	OutcomePasswordExpired    SignInOutcome = "password_expired"
+)
+
+type SignInAttempt struct {
+	AttemptID      uint64        `gorm:"primaryKey;autoIncrement" json:"attempt_id"`
+	Email          string        `gorm:"size:150;not null" json:"email"`
+	UserID         *uint64       `json:"user_id,omitempty"`
+	OrganizationID *uint64       `json:"organization_id,omitempty"`
+	IPAddress      string        `gorm:"size:45;not null" json:"ip_address"`
+	UserAgent      string        `gorm:"type:text" json:"user_agent"`
+	Outcome        SignInOutcome `gorm:"type:varchar(30);not null" json:"outcome"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+func (SignInAttempt) TableName() string {
+	return "sign_in_attempts"
+}
+
+func (a *SignInAttempt) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	return nil
+}
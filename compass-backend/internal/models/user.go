@@ -10,8 +10,9 @@ type UserRole string
 type AccountStatus string
 
 const (
-	RoleAdmin UserRole = "admin"
-	RoleUser  UserRole = "user"
+	RoleSuperAdmin UserRole = "super_admin"
+	RoleAdmin      UserRole = "admin"
+	RoleUser       UserRole = "user"
 
 	StatusPending  AccountStatus = "pending"
 	StatusActive   AccountStatus = "active"
@@ -19,22 +20,57 @@ const (
 )
 
 type User struct {
-	UserID        uint64        `gorm:"primaryKey;autoIncrement" json:"user_id"`
-	FullName      string        `gorm:"size:150;not null" json:"full_name"`
-	Email         string        `gorm:"size:150;uniqueIndex;not null" json:"email"`
-	PasswordHash  *string       `gorm:"size:255" json:"-"`
-	Role          UserRole      `gorm:"type:varchar(20);default:'user';check:role IN ('admin','user')" json:"role"`
-	AccountStatus AccountStatus `gorm:"type:varchar(20);default:'pending';check:account_status IN ('pending','active','disabled')" json:"account_status"`
-	InvitedBy     *uint64       `json:"invited_by,omitempty"`
-	InvitedByUser *User         `gorm:"foreignKey:InvitedBy" json:"invited_by_user,omitempty"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
+	UserID              uint64        `gorm:"primaryKey;autoIncrement" json:"user_id"`
+	OrganizationID      *uint64       `json:"organization_id,omitempty"`
+	FullName            string        `gorm:"size:150;not null" json:"full_name"`
+	Email               string        `gorm:"size:150;uniqueIndex;not null" json:"email"`
+	PasswordHash        *string       `gorm:"size:255" json:"-"`
+	PasswordChangedAt   *time.Time    `json:"password_changed_at,omitempty"`
+	Role                UserRole      `gorm:"type:varchar(50);default:'user'" json:"role"`
+	AccountStatus       AccountStatus `gorm:"type:varchar(20);default:'pending';check:account_status IN ('pending','active','disabled')" json:"account_status"`
+	InvitedBy           *uint64       `json:"invited_by,omitempty"`
+	InvitedByUser       *User         `gorm:"foreignKey:InvitedBy" json:"invited_by_user,omitempty"`
+	IsServiceAccount    bool          `gorm:"not null;default:false" json:"is_service_account"`
+	TokenVersion        int           `gorm:"not null;default:0" json:"-"`
+	MFAEnabled          bool          `gorm:"column:mfa_enabled;not null;default:false" json:"mfa_enabled"`
+	TOTPSecret          *string       `gorm:"column:totp_secret;size:64" json:"-"`
+	TOTPLastStep        *int64        `gorm:"column:totp_last_step" json:"-"`
+	MFAEnabledAt        *time.Time    `gorm:"column:mfa_enabled_at" json:"mfa_enabled_at,omitempty"`
+	FailedLoginAttempts int           `gorm:"not null;default:0" json:"failed_login_attempts"`
+	LockedUntil         *time.Time    `json:"locked_until,omitempty"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+
+	// Profile settings the user manages themselves
+	Timezone          string                  `gorm:"size:64;not null;default:'UTC'" json:"timezone"`
+	NotificationPrefs NotificationPreferences `gorm:"embedded;embeddedPrefix:notify_" json:"notification_preferences"`
+	AvatarUpdatedAt   *time.Time              `json:"avatar_updated_at,omitempty"`
+
+	// Set once the user's personal data has been erased
+	ErasedAt *time.Time `json:"erased_at,omitempty"`
+}
+
+// NotificationPreferences selects which optional emails a user receives.
+// Security notices such as email changes are always sent.
+type NotificationPreferences struct {
+	RFIUpdates     bool `gorm:"not null;default:true" json:"rfi_updates"`
+	ProjectUpdates bool `gorm:"not null;default:true" json:"project_updates"`
+	WeeklyDigest   bool `gorm:"not null;default:false" json:"weekly_digest"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
 
+// OrgID returns the user's organization, or 0 for platform super-admins who
+// belong to none.
+func (u *User) OrgID() uint64 {
+	if u.OrganizationID == nil {
+		return 0
+	}
+	return *u.OrganizationID
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	u.CreatedAt = time.Now()
 	u.UpdatedAt = time.Now()
@@ -44,4 +80,4 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 func (u *User) BeforeUpdate(tx *gorm.DB) error {
 	u.UpdatedAt = time.Now()
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserAvatar is the profile picture a user uploaded.
+type UserAvatar struct {
+	UserID      uint64    `gorm:"primaryKey" json:"user_id"`
+	ContentType string    `gorm:"size:50;not null" json:"content_type"`
+	Data        []byte    `gorm:"not null" json:"-"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (UserAvatar) TableName() string {
+	return "user_avatars"
+}
+
+// EmailChangeRequest is a pending change of a user's email address. It is
+// applied once the single-use token mailed to the new address is presented.
+// Only the SHA-256 hash of the token is stored.
+type EmailChangeRequest struct {
+	RequestID uint64     `gorm:"primaryKey;autoIncrement" json:"request_id"`
+	UserID    uint64     `gorm:"not null;index" json:"user_id"`
+	NewEmail  string     `gorm:"size:150;not null" json:"new_email"`
+	TokenHash string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (EmailChangeRequest) TableName() string {
+	return "email_change_requests"
+}
+
+func (r *EmailChangeRequest) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,278 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "compass"
+
+// mockIdP is a minimal OpenID Connect provider. It publishes one RSA key,
+// remembers the PKCE challenge of the last authorization request and only
+// issues the configured ID token for a matching code verifier.
+type mockIdP struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	challenge string
+	idToken   string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	idp := &mockIdP{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	base := idp.server.URL
+	json.NewEncoder(w).Encode(Discovery{
+		Issuer:                base,
+		AuthorizationEndpoint: base + "/authorize",
+		TokenEndpoint:         base + "/token",
+		JWKSURI:               base + "/jwks",
+	})
+}
+
+func (idp *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := idp.key.PublicKey
+	json.NewEncoder(w).Encode(jwkSet{Keys: []jwk{{
+		Kty: "RSA",
+		Kid: "test-key",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idp.idToken})
+}
+
+// sign issues an ID token with sensible defaults that the caller can adjust.
+func (idp *mockIdP) sign(key *rsa.PrivateKey, nonce string, edit func(*IDTokenClaims)) string {
+	idp.t.Helper()
+
+	now := time.Now()
+	claims := &IDTokenClaims{
+		Nonce: nonce,
+		Email: "user@example.com",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    idp.server.URL,
+			Subject:   "subject-1",
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+	}
+	if edit != nil {
+		edit(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		idp.t.Fatalf("sign id token: %v", err)
+	}
+	return signed
+}
+
+func (idp *mockIdP) provider() *Provider {
+	return NewProvider(Config{
+		IssuerURL:   idp.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "https://compass.example.com/sso/callback",
+		Scopes:      []string{"openid", "email"},
+	}, idp.server.Client())
+}
+
+// begin starts a login the way the SSO service does and records the PKCE
+// challenge the provider was sent.
+func (idp *mockIdP) begin(p *Provider) (verifier, nonce string) {
+	idp.t.Helper()
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		idp.t.Fatalf("pkce: %v", err)
+	}
+	nonce, err = RandomString(32)
+	if err != nil {
+		idp.t.Fatalf("nonce: %v", err)
+	}
+
+	authURL, err := p.AuthCodeURL(context.Background(), "state-1", nonce, challenge)
+	if err != nil {
+		idp.t.Fatalf("auth code url: %v", err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		idp.t.Fatalf("parse auth url: %v", err)
+	}
+
+	query := parsed.Query()
+	if query.Get("state") != "state-1" || query.Get("nonce") != nonce {
+		idp.t.Fatalf("auth url does not carry state and nonce: %s", authURL)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") != challenge {
+		idp.t.Fatalf("auth url does not carry the S256 challenge: %s", authURL)
+	}
+	idp.challenge = query.Get("code_challenge")
+	return verifier, nonce
+}
+
+func TestProviderCompletesLogin(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider()
+	verifier, nonce := idp.begin(p)
+	idp.idToken = idp.sign(idp.key, nonce, nil)
+
+	raw, err := p.Exchange(context.Background(), "good-code", verifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+	claims, err := p.VerifyIDToken(context.Background(), raw, nonce)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if claims.Subject != "subject-1" || claims.Email != "user@example.com" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestProviderRejectsWrongCodeVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider()
+	_, nonce := idp.begin(p)
+	idp.idToken = idp.sign(idp.key, nonce, nil)
+
+	other, _, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("pkce: %v", err)
+	}
+	if _, err := p.Exchange(context.Background(), "good-code", other); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("expected invalid_grant, got %v", err)
+	}
+}
+
+func TestProviderRejectsDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+
+	// Same server under another host name, so the document's issuer differs
+	// from the configured one
+	issuer := strings.Replace(idp.server.URL, "127.0.0.1", "localhost", 1)
+	p := NewProvider(Config{IssuerURL: issuer, ClientID: testClientID}, idp.server.Client())
+	if _, err := p.Discover(context.Background()); err == nil || !strings.Contains(err.Error(), "issuer") {
+		t.Fatalf("expected discovery issuer mismatch to be rejected, got %v", err)
+	}
+}
+
+func TestProviderRejectsInvalidIDTokens(t *testing.T) {
+	idp := newMockIdP(t)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token func(nonce string) string
+		nonce func(nonce string) string
+	}{
+		{
+			name:  "bad signature",
+			token: func(nonce string) string { return idp.sign(otherKey, nonce, nil) },
+		},
+		{
+			name: "wrong audience",
+			token: func(nonce string) string {
+				return idp.sign(idp.key, nonce, func(c *IDTokenClaims) { c.Audience = jwt.ClaimStrings{"someone-else"} })
+			},
+		},
+		{
+			name: "wrong issuer",
+			token: func(nonce string) string {
+				return idp.sign(idp.key, nonce, func(c *IDTokenClaims) { c.Issuer = "https://evil.example.com" })
+			},
+		},
+		{
+			name: "expired",
+			token: func(nonce string) string {
+				return idp.sign(idp.key, nonce, func(c *IDTokenClaims) {
+					c.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+					c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Minute))
+				})
+			},
+		},
+		{
+			name:  "nonce mismatch",
+			token: func(nonce string) string { return idp.sign(idp.key, nonce, nil) },
+			nonce: func(string) string { return "another-nonce" },
+		},
+		{
+			name: "missing subject",
+			token: func(nonce string) string {
+				return idp.sign(idp.key, nonce, func(c *IDTokenClaims) { c.Subject = "" })
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := idp.provider()
+			verifier, nonce := idp.begin(p)
+			idp.idToken = tt.token(nonce)
+
+			raw, err := p.Exchange(context.Background(), "good-code", verifier)
+			if err != nil {
+				t.Fatalf("exchange: %v", err)
+			}
+
+			expected := nonce
+			if tt.nonce != nil {
+				expected = tt.nonce(nonce)
+			}
+			if _, err := p.VerifyIDToken(context.Background(), raw, expected); err == nil {
+				t.Fatal("expected the id token to be rejected")
+			}
+		})
+	}
+}
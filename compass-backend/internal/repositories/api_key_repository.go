@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	FindByPrefix(prefix string) (*models.APIKey, error)
+	ListByUser(orgID, userID uint64) ([]models.APIKey, error)
+	Revoke(orgID, userID, keyID uint64) (bool, error)
+	TouchLastUsed(keyID uint64, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create stores the key together with its scopes.
+func (r *apiKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(key).Error; err != nil {
+			return err
+		}
+		for _, p := range key.Scopes {
+			if err := tx.Create(&models.APIKeyScope{KeyID: key.KeyID, PermissionName: p}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *apiKeyRepository) FindByPrefix(prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	if err := r.loadScopes(&key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListByUser(orgID, userID uint64) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Scopes(inOrganization("api_keys", orgID)).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		if err := r.loadScopes(&keys[i]); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// Revoke marks an active key as revoked and reports whether one was found.
+func (r *apiKeyRepository) Revoke(orgID, userID, keyID uint64) (bool, error) {
+	result := r.db.Model(&models.APIKey{}).
+		Scopes(inOrganization("api_keys", orgID)).
+		Where("key_id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *apiKeyRepository) TouchLastUsed(keyID uint64, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("key_id = ?", keyID).Update("last_used_at", at).Error
+}
+
+func (r *apiKeyRepository) loadScopes(key *models.APIKey) error {
+	key.Scopes = []models.Permission{}
+	return r.db.Model(&models.APIKeyScope{}).
+		Where("key_id = ?", key.KeyID).
+		Order("permission_name").
+		Pluck("permission_name", &key.Scopes).Error
+}
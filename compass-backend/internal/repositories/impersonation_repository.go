@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type ImpersonationFilter struct {
+	OrganizationID uint64
+	ActorID        *uint64
+	UserID         *uint64
+	Since          *time.Time
+	Limit          int
+}
+
+type ImpersonationRepository interface {
+	Create(impersonation *models.Impersonation) error
+	FindByID(orgID, id uint64) (*models.Impersonation, error)
+	FindActive(id uint64) (*models.Impersonation, error)
+	List(filter ImpersonationFilter) ([]models.Impersonation, error)
+	End(id uint64) error
+	CreateRequest(request *models.ImpersonationRequest) error
+}
+
+type impersonationRepository struct {
+	db *gorm.DB
+}
+
+func NewImpersonationRepository(db *gorm.DB) ImpersonationRepository {
+	return &impersonationRepository{db: db}
+}
+
+func (r *impersonationRepository) Create(impersonation *models.Impersonation) error {
+	return r.db.Create(impersonation).Error
+}
+
+// FindByID returns an impersonation together with every request made during
+// it.
+func (r *impersonationRepository) FindByID(orgID, id uint64) (*models.Impersonation, error) {
+	var impersonation models.Impersonation
+	err := r.db.Scopes(inOrganization("impersonations", orgID)).
+		Preload("Actor").
+		Preload("User").
+		Preload("Requests", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC, request_id ASC")
+		}).
+		First(&impersonation, "impersonation_id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &impersonation, nil
+}
+
+func (r *impersonationRepository) FindActive(id uint64) (*models.Impersonation, error) {
+	var impersonation models.Impersonation
+	err := r.db.Where("impersonation_id = ? AND ended_at IS NULL AND expires_at > ?", id, time.Now()).
+		First(&impersonation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &impersonation, nil
+}
+
+func (r *impersonationRepository) List(filter ImpersonationFilter) ([]models.Impersonation, error) {
+	query := r.db.Model(&models.Impersonation{}).Scopes(inOrganization("impersonations", filter.OrganizationID))
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+
+	var impersonations []models.Impersonation
+	err := query.Preload("Actor").Preload("User").
+		Order("created_at DESC").
+		Limit(filter.Limit).
+		Find(&impersonations).Error
+	return impersonations, err
+}
+
+func (r *impersonationRepository) End(id uint64) error {
+	return r.db.Model(&models.Impersonation{}).
+		Where("impersonation_id = ? AND ended_at IS NULL", id).
+		Update("ended_at", time.Now()).Error
+}
+
+func (r *impersonationRepository) CreateRequest(request *models.ImpersonationRequest) error {
+	return r.db.Create(request).Error
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// errInviteNotPending rolls back an accepted invite whose user is no longer
+// pending.
+var errInviteNotPending = errors.New("invited user is no longer pending")
+
+type InviteRepository interface {
+	Create(invite *models.UserInvite) error
+	FindByHash(tokenHash string) (*models.UserInvite, error)
+	Accept(invite *models.UserInvite, hashedPassword string) (bool, error)
+	RevokeActiveForUser(userID uint64) (int64, error)
+}
+
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+func NewInviteRepository(db *gorm.DB) InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+func (r *inviteRepository) Create(invite *models.UserInvite) error {
+	return r.db.Create(invite).Error
+}
+
+func (r *inviteRepository) FindByHash(tokenHash string) (*models.UserInvite, error) {
+	var invite models.UserInvite
+	err := r.db.Where("token_hash = ?", tokenHash).First(&invite).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// Accept consumes an invite and activates its pending user with the password,
+// in a single transaction. It reports false without changing anything when
+// the invite was already accepted, revoked or has expired, or the user is no
+// longer pending.
+func (r *inviteRepository) Accept(invite *models.UserInvite, hashedPassword string) (bool, error) {
+	accepted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.UserInvite{}).
+			Where("invite_id = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", invite.InviteID, now).
+			Update("accepted_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		result = tx.Model(&models.User{}).
+			Where("user_id = ? AND account_status = ?", invite.UserID, models.StatusPending).
+			Updates(map[string]interface{}{
+				"password_hash":       hashedPassword,
+				"password_changed_at": now,
+				"account_status":      models.StatusActive,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Keep the invite unused
+			return errInviteNotPending
+		}
+
+		accepted = true
+		return nil
+	})
+	if errors.Is(err, errInviteNotPending) {
+		return false, nil
+	}
+	return accepted, err
+}
+
+func (r *inviteRepository) RevokeActiveForUser(userID uint64) (int64, error) {
+	result := r.db.Model(&models.UserInvite{}).
+		Where("user_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
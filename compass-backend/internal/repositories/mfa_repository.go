@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type MFARepository interface {
+	SetPendingSecret(userID uint64, secret string) error
+	Enable(userID uint64) error
+	Disable(userID uint64) error
+	ReplaceRecoveryCodes(userID uint64, codeHashes []string) error
+	UseRecoveryCode(userID uint64, codeHash string) (bool, error)
+	UseTOTPStep(userID uint64, step int64) (bool, error)
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) SetPendingSecret(userID uint64, secret string) error {
+	return r.db.Model(&models.User{}).Where("user_id = ? AND mfa_enabled = ?", userID, false).
+		Update("totp_secret", secret).Error
+}
+
+func (r *mfaRepository) Enable(userID uint64) error {
+	return r.db.Model(&models.User{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"mfa_enabled":    true,
+		"mfa_enabled_at": time.Now(),
+	}).Error
+}
+
+func (r *mfaRepository) Disable(userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"mfa_enabled":    false,
+			"mfa_enabled_at": nil,
+			"totp_secret":    nil,
+			"totp_last_step": nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(userID uint64, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		for _, hash := range codeHashes {
+			if err := tx.Create(&models.RecoveryCode{UserID: userID, CodeHash: hash}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// UseRecoveryCode consumes a matching unused recovery code and reports whether
+// one was found.
+func (r *mfaRepository) UseRecoveryCode(userID uint64, codeHash string) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// UseTOTPStep records that a TOTP code of the given time step was accepted. It
+// reports false when a code of that step or a later one was already accepted,
+// so that each code works once even under concurrent use.
+func (r *mfaRepository) UseTOTPStep(userID uint64, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("user_id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
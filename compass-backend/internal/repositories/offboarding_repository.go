@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OffboardingPlan lists what moves from a departing user to their successor.
+type OffboardingPlan struct {
+	UserID             uint64   `json:"user_id"`
+	SuccessorID        uint64   `json:"successor_id"`
+	CreatedProjectIDs  []uint64 `json:"created_project_ids"`
+	OwnedProjectIDs    []uint64 `json:"owned_project_ids"`
+	MembershipsRemoved int64    `json:"memberships_removed"`
+	InvitedUserIDs     []uint64 `json:"invited_user_ids"`
+	OpenRFIs           int64    `json:"open_rfis"`
+	SessionsEnded      int64    `json:"sessions_ended"`
+	APIKeysRevoked     int64    `json:"api_keys_revoked"`
+}
+
+type OffboardingRepository interface {
+	Plan(orgID, userID, successorID uint64) (*OffboardingPlan, error)
+	Offboard(orgID, userID, successorID, actorID uint64) (*OffboardingPlan, error)
+}
+
+type offboardingRepository struct {
+	db *gorm.DB
+}
+
+func NewOffboardingRepository(db *gorm.DB) OffboardingRepository {
+	return &offboardingRepository{db: db}
+}
+
+// Plan works out what offboarding the user would change without changing it.
+func (r *offboardingRepository) Plan(orgID, userID, successorID uint64) (*OffboardingPlan, error) {
+	return planOffboarding(r.db, orgID, userID, successorID)
+}
+
+// Offboard disables the user, ends their sessions and API keys, and hands
+// their projects and invited users to the successor in a single transaction.
+func (r *offboardingRepository) Offboard(orgID, userID, successorID, actorID uint64) (*OffboardingPlan, error) {
+	var plan *OffboardingPlan
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the departing user so that concurrent offboardings of the same
+		// account run one after the other
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		if err := keepActiveAdmin(tx, userID); err != nil {
+			return err
+		}
+
+		var err error
+		plan, err = planOffboarding(tx, orgID, userID, successorID)
+		if err != nil {
+			return err
+		}
+
+		if len(plan.CreatedProjectIDs) > 0 {
+			err := tx.Unscoped().Model(&models.Project{}).Where("project_id IN ?", plan.CreatedProjectIDs).
+				Update("created_by", successorID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// The successor owns every project the user owned, deleted ones
+		// included, keeping any membership they already had but raising it
+		// to owner
+		for _, projectID := range plan.OwnedProjectIDs {
+			owner := &models.ProjectMember{
+				ProjectID:  projectID,
+				UserID:     successorID,
+				MemberRole: models.MemberOwner,
+				AddedBy:    &actorID,
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "project_id"}, {Name: "user_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"member_role": models.MemberOwner}),
+			}).Create(owner).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Scopes(anyProjectInOrganization(orgID)).Where("user_id = ?", userID).
+			Delete(&models.ProjectMember{}).Error
+		if err != nil {
+			return err
+		}
+
+		if len(plan.InvitedUserIDs) > 0 {
+			err := tx.Model(&models.User{}).Where("user_id IN ?", plan.InvitedUserIDs).
+				Update("invited_by", successorID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// Bumping the token version makes every outstanding access token
+		// unusable at once
+		err = tx.Model(&models.User{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"account_status": models.StatusDisabled,
+			"token_version":  gorm.Expr("token_version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func planOffboarding(db *gorm.DB, orgID, userID, successorID uint64) (*OffboardingPlan, error) {
+	plan := &OffboardingPlan{
+		UserID:            userID,
+		SuccessorID:       successorID,
+		CreatedProjectIDs: []uint64{},
+		OwnedProjectIDs:   []uint64{},
+		InvitedUserIDs:    []uint64{},
+	}
+
+	// Deleted projects are included so that they have a creator if restored
+	err := db.Unscoped().Model(&models.Project{}).Scopes(inOrganization("projects", orgID)).
+		Where("created_by = ?", userID).Order("project_id").Pluck("project_id", &plan.CreatedProjectIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&models.ProjectMember{}).Scopes(anyProjectInOrganization(orgID)).
+		Where("user_id = ? AND member_role = ?", userID, models.MemberOwner).
+		Order("project_id").Pluck("project_id", &plan.OwnedProjectIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&models.ProjectMember{}).Scopes(anyProjectInOrganization(orgID)).
+		Where("user_id = ?", userID).Count(&plan.MembershipsRemoved).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&models.User{}).Scopes(inOrganization("users", orgID)).
+		Where("invited_by = ?", userID).Order("user_id").Pluck("user_id", &plan.InvitedUserIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Unanswered RFIs of the projects the successor takes over
+	projectIDs := append(append([]uint64{}, plan.CreatedProjectIDs...), plan.OwnedProjectIDs...)
+	if len(projectIDs) > 0 {
+		err = db.Model(&models.ProjectRFI{}).
+			Where("project_id IN ? AND answer_value IS NULL", projectIDs).Count(&plan.OpenRFIs).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = db.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&plan.SessionsEnded).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Model(&models.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).
+		Count(&plan.APIKeysRevoked).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type OIDCRepository interface {
+	CreateAuthRequest(req *models.OIDCAuthRequest) error
+	ConsumeAuthRequest(stateHash string) (*models.OIDCAuthRequest, error)
+	FindIdentity(issuer, subject string) (*models.UserIdentity, error)
+	CreateIdentity(identity *models.UserIdentity) error
+	TouchIdentity(id uint64, email string, at time.Time) error
+}
+
+type oidcRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCRepository(db *gorm.DB) OIDCRepository {
+	return &oidcRepository{db: db}
+}
+
+func (r *oidcRepository) CreateAuthRequest(req *models.OIDCAuthRequest) error {
+	return r.db.Create(req).Error
+}
+
+// ConsumeAuthRequest marks the login with the given state as used and returns
+// it. A state can only be consumed once and only before it expires.
+func (r *oidcRepository) ConsumeAuthRequest(stateHash string) (*models.OIDCAuthRequest, error) {
+	var req models.OIDCAuthRequest
+	err := r.db.Where("state_hash = ?", stateHash).First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := r.db.Model(&models.OIDCAuthRequest{}).
+		Where("request_id = ? AND used_at IS NULL AND expires_at > ?", req.RequestID, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected != 1 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &req, nil
+}
+
+func (r *oidcRepository) FindIdentity(issuer, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *oidcRepository) CreateIdentity(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+func (r *oidcRepository) TouchIdentity(id uint64, email string, at time.Time) error {
+	return r.db.Model(&models.UserIdentity{}).Where("identity_id = ?", id).Updates(map[string]interface{}{
+		"email":         email,
+		"last_login_at": at,
+	}).Error
+}
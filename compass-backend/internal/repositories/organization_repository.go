@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository interface {
+	Create(org *models.Organization) error
+	FindByID(id uint64) (*models.Organization, error)
+	FindBySlug(slug string) (*models.Organization, error)
+	List() ([]models.Organization, error)
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+func (r *organizationRepository) Create(org *models.Organization) error {
+	return r.db.Create(org).Error
+}
+
+func (r *organizationRepository) FindByID(id uint64) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.First(&org, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) FindBySlug(slug string) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.Where("slug = ?", slug).First(&org).Error
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+func (r *organizationRepository) List() ([]models.Organization, error) {
+	var orgs []models.Organization
+	err := r.db.Order("name").Find(&orgs).Error
+	return orgs, err
+}
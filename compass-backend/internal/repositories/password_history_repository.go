@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository interface {
+	Create(entry *models.PasswordHistory) error
+	ListRecent(userID uint64, limit int) ([]models.PasswordHistory, error)
+	Prune(userID uint64, keep int) error
+}
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: db}
+}
+
+func (r *passwordHistoryRepository) Create(entry *models.PasswordHistory) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *passwordHistoryRepository) ListRecent(userID uint64, limit int) ([]models.PasswordHistory, error) {
+	var entries []models.PasswordHistory
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC, history_id DESC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// Prune deletes all but the user's keep most recent entries.
+func (r *passwordHistoryRepository) Prune(userID uint64, keep int) error {
+	recent := r.db.Model(&models.PasswordHistory{}).
+		Select("history_id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC, history_id DESC").
+		Limit(keep)
+	return r.db.Where("user_id = ? AND history_id NOT IN (?)", userID, recent).
+		Delete(&models.PasswordHistory{}).Error
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository interface {
+	Create(token *models.PasswordResetToken) error
+	FindByHash(tokenHash string) (*models.PasswordResetToken, error)
+	Redeem(reset *models.PasswordResetToken, hashedPassword string) (bool, error)
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+func (r *passwordResetRepository) Create(token *models.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *passwordResetRepository) FindByHash(tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	err := r.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Redeem consumes a reset token, sets the user's password and invalidates the
+// user's other outstanding tokens, in a single transaction. It reports false
+// without changing anything when the token was already used or has expired.
+func (r *passwordResetRepository) Redeem(reset *models.PasswordResetToken, hashedPassword string) (bool, error) {
+	redeemed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("reset_id = ? AND used_at IS NULL AND expires_at > ?", reset.ResetID, now).
+			Update("used_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		err := tx.Model(&models.User{}).Where("user_id = ?", reset.UserID).Updates(map[string]interface{}{
+			"password_hash":       hashedPassword,
+			"password_changed_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		// Any other outstanding links are now stale
+		err = tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", reset.UserID).
+			Update("used_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		redeemed = true
+		return nil
+	})
+	return redeemed, err
+}
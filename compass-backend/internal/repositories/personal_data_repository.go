@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// InvitedUser is a user someone invited, reduced to what identifies them in a
+// personal data export of the inviter.
+type InvitedUser struct {
+	UserID    uint64    `json:"user_id"`
+	FullName  string    `json:"full_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportedAvatar is a user's avatar image. Data is base64 encoded in JSON.
+type ExportedAvatar struct {
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// PersonalData is everything stored about a user or recorded as done by them.
+type PersonalData struct {
+	ExportedAt             time.Time                     `json:"exported_at"`
+	Profile                models.User                   `json:"profile"`
+	Avatar                 *ExportedAvatar               `json:"avatar,omitempty"`
+	Identities             []models.UserIdentity         `json:"sso_identities"`
+	ProjectMemberships     []models.ProjectMember        `json:"project_memberships"`
+	ProjectsCreated        []models.Project              `json:"projects_created"`
+	ProjectsLastUpdated    []models.Project              `json:"projects_last_updated"`
+	ProjectStatusChanges   []models.ProjectStatusChange  `json:"project_status_changes"`
+	SpecificationsAuthored []models.ProjectSpecification `json:"specifications_authored"`
+	RFIsAnswered           []models.ProjectRFI           `json:"rfis_answered"`
+	InvitedUsers           []InvitedUser                 `json:"invited_users"`
+	Sessions               []models.Session              `json:"sessions"`
+	SignInAttempts         []models.SignInAttempt        `json:"sign_in_attempts"`
+	Impersonations         []models.Impersonation        `json:"impersonations"`
+}
+
+// Pseudonym replaces the identifying fields of an erased user.
+type Pseudonym struct {
+	FullName string
+	Email    string
+}
+
+type PersonalDataRepository interface {
+	Collect(orgID, userID uint64) (*PersonalData, error)
+	Erase(userID uint64, pseudonym Pseudonym) error
+}
+
+type personalDataRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalDataRepository(db *gorm.DB) PersonalDataRepository {
+	return &personalDataRepository{db: db}
+}
+
+// Collect gathers the user's personal data from every table that holds some.
+func (r *personalDataRepository) Collect(orgID, userID uint64) (*PersonalData, error) {
+	data := &PersonalData{ExportedAt: time.Now()}
+	if err := r.db.Scopes(inOrganization("users", orgID)).First(&data.Profile, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var avatar models.UserAvatar
+	err := r.db.Where("user_id = ?", userID).Limit(1).Find(&avatar).Error
+	if err != nil {
+		return nil, err
+	}
+	if avatar.UserID != 0 {
+		data.Avatar = &ExportedAvatar{ContentType: avatar.ContentType, Data: avatar.Data}
+	}
+
+	queries := []struct {
+		query *gorm.DB
+		dest  interface{}
+	}{
+		{r.db.Where("user_id = ?", userID), &data.Identities},
+		{r.db.Where("user_id = ?", userID).Order("project_id"), &data.ProjectMemberships},
+		{r.db.Unscoped().Scopes(inOrganization("projects", orgID)).Where("created_by = ?", userID).Order("project_id"), &data.ProjectsCreated},
+		{r.db.Unscoped().Scopes(inOrganization("projects", orgID)).Where("last_updated_by = ?", userID).Order("project_id"), &data.ProjectsLastUpdated},
+		{r.db.Scopes(projectInOrganization(orgID)).Where("changed_by = ?", userID).Order("history_id"), &data.ProjectStatusChanges},
+		{r.db.Scopes(projectInOrganization(orgID)).Where("created_by = ?", userID).Order("specification_id"), &data.SpecificationsAuthored},
+		{r.db.Scopes(projectInOrganization(orgID)).Where("answered_by = ?", userID).Order("rfi_id"), &data.RFIsAnswered},
+		{r.db.Model(&models.User{}).Where("invited_by = ?", userID).Order("user_id"), &data.InvitedUsers},
+		{r.db.Where("user_id = ?", userID).Order("created_at"), &data.Sessions},
+		{r.db.Where("user_id = ? OR email = ?", userID, data.Profile.Email).Order("created_at"), &data.SignInAttempts},
+		{r.db.Preload("Requests").Where("actor_id = ? OR user_id = ?", userID, userID).Order("created_at"), &data.Impersonations},
+	}
+	for _, q := range queries {
+		if err := q.query.Find(q.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// Erase replaces the user's identifying fields with the pseudonym and removes
+// their credentials, sessions and other personal records in a single
+// transaction. The user row stays so that everything they created, updated or
+// answered keeps pointing at it.
+func (r *personalDataRepository) Erase(userID uint64, pseudonym Pseudonym) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return err
+		}
+		if err := keepActiveAdmin(tx, userID); err != nil {
+			return err
+		}
+
+		err := tx.Model(&models.User{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"full_name":             pseudonym.FullName,
+			"email":                 pseudonym.Email,
+			"password_hash":         nil,
+			"password_changed_at":   nil,
+			"account_status":        models.StatusDisabled,
+			"token_version":         gorm.Expr("token_version + 1"),
+			"mfa_enabled":           false,
+			"mfa_enabled_at":        nil,
+			"totp_secret":           nil,
+			"totp_last_step":        nil,
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+			"timezone":              "UTC",
+			"avatar_updated_at":     nil,
+			"erased_at":             time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		// Credentials, sessions and pending links are personal data that
+		// nothing else refers to
+		for _, record := range []interface{}{
+			&models.UserAvatar{},
+			&models.UserIdentity{},
+			&models.Session{},
+			&models.RefreshToken{},
+			&models.PasswordHistory{},
+			&models.PasswordResetToken{},
+			&models.RecoveryCode{},
+			&models.EmailChangeRequest{},
+			&models.UserInvite{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+
+		// Audit records are kept without the details that identify the user
+		err = tx.Model(&models.SignInAttempt{}).Where("user_id = ? OR email = ?", userID, user.Email).
+			Updates(map[string]interface{}{"email": pseudonym.Email, "ip_address": "", "user_agent": ""}).Error
+		if err != nil {
+			return err
+		}
+
+		actorImpersonations := tx.Session(&gorm.Session{NewDB: true}).
+			Model(&models.Impersonation{}).Select("impersonation_id").Where("actor_id = ?", userID)
+		err = tx.Model(&models.ImpersonationRequest{}).Where("impersonation_id IN (?)", actorImpersonations).
+			Update("ip_address", "").Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Impersonation{}).Where("actor_id = ?", userID).Update("ip_address", "").Error
+	})
+}
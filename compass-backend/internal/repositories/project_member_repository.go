@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type ProjectMemberRepository interface {
+	Create(member *models.ProjectMember) error
+	Find(projectID, userID uint64) (*models.ProjectMember, error)
+	ListByProject(projectID uint64) ([]models.ProjectMember, error)
+	UpdateRole(projectID, userID uint64, role models.ProjectMemberRole) error
+	Delete(projectID, userID uint64) error
+	CountOwners(projectID uint64) (int64, error)
+}
+
+type projectMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewProjectMemberRepository(db *gorm.DB) ProjectMemberRepository {
+	return &projectMemberRepository{db: db}
+}
+
+func (r *projectMemberRepository) Create(member *models.ProjectMember) error {
+	return r.db.Create(member).Error
+}
+
+func (r *projectMemberRepository) Find(projectID, userID uint64) (*models.ProjectMember, error) {
+	var member models.ProjectMember
+	err := r.db.Where("project_id = ? AND user_id = ?", projectID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *projectMemberRepository) ListByProject(projectID uint64) ([]models.ProjectMember, error) {
+	var members []models.ProjectMember
+	err := r.db.Where("project_id = ?", projectID).
+		Preload("User").
+		Order("created_at").
+		Find(&members).Error
+	return members, err
+}
+
+func (r *projectMemberRepository) UpdateRole(projectID, userID uint64, role models.ProjectMemberRole) error {
+	return r.db.Model(&models.ProjectMember{}).
+		Where("project_id = ? AND user_id = ?", projectID, userID).
+		Update("member_role", role).Error
+}
+
+func (r *projectMemberRepository) Delete(projectID, userID uint64) error {
+	return r.db.Where("project_id = ? AND user_id = ?", projectID, userID).
+		Delete(&models.ProjectMember{}).Error
+}
+
+func (r *projectMemberRepository) CountOwners(projectID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ProjectMember{}).
+		Where("project_id = ? AND member_role = ?", projectID, models.MemberOwner).
+		Count(&count).Error
+	return count, err
+}
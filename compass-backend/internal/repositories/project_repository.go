@@ -1,16 +1,115 @@
 package repositories
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	"compass-backend/internal/models"
+	"compass-backend/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProjectSort string
+
+const (
+	ProjectSortName      ProjectSort = "name"
+	ProjectSortCompany   ProjectSort = "company"
+	ProjectSortCreatedAt ProjectSort = "created_at"
+	ProjectSortUpdatedAt ProjectSort = "updated_at"
+)
+
+// projectSortColumns maps each sort to the expression projects are ordered
+// by.
+var projectSortColumns = map[ProjectSort]string{
+	ProjectSortName:      "LOWER(projects.project_name)",
+	ProjectSortCompany:   "LOWER(COALESCE(projects.company_name, ''))",
+	ProjectSortCreatedAt: "projects.created_at",
+	ProjectSortUpdatedAt: "projects.updated_at",
+}
+
+// IsValid reports whether the sort is one projects can be ordered by.
+func (s ProjectSort) IsValid() bool {
+	_, ok := projectSortColumns[s]
+	return ok
+}
+
+func (s ProjectSort) isTime() bool {
+	return s == ProjectSortCreatedAt || s == ProjectSortUpdatedAt
+}
+
+// ProjectArchiveFilter selects how archived projects appear in a listing.
+type ProjectArchiveFilter string
+
+const (
+	ArchivedExclude ProjectArchiveFilter = ""
+	ArchivedInclude ProjectArchiveFilter = "include"
+	ArchivedOnly    ProjectArchiveFilter = "only"
 )
 
+type ProjectFilter struct {
+	OrganizationID uint64
+	MemberID       *uint64
+	Statuses       []models.ProjectStatus
+	Types          []models.ProjectType
+	Company        string
+	CreatedBy      *uint64
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+	UpdatedFrom    *time.Time
+	UpdatedTo      *time.Time
+	Search         string
+	Archived       ProjectArchiveFilter
+	Sort           ProjectSort
+	Descending     bool
+	After          *ProjectCursor
+	Limit          int
+}
+
+// ProjectCursor is the position of a project in the order of a filter.
+// Projects are ordered by the sort value and then by ID, so positions are
+// unique.
+type ProjectCursor struct {
+	Sort       ProjectSort `json:"s"`
+	Descending bool        `json:"d,omitempty"`
+	Value      string      `json:"v"`
+	ID         uint64      `json:"id"`
+}
+
+// CursorFor returns the position of project in the filter's order.
+func (f ProjectFilter) CursorFor(project *models.Project) ProjectCursor {
+	cursor := ProjectCursor{Sort: f.Sort, Descending: f.Descending, ID: project.ProjectID}
+	switch f.Sort {
+	case ProjectSortName:
+		cursor.Value = strings.ToLower(project.ProjectName)
+	case ProjectSortCompany:
+		cursor.Value = strings.ToLower(project.CompanyName)
+	case ProjectSortCreatedAt:
+		cursor.Value = project.CreatedAt.Format(time.RFC3339Nano)
+	case ProjectSortUpdatedAt:
+		cursor.Value = project.UpdatedAt.Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
 type ProjectRepository interface {
 	Create(project *models.Project) error
-	FindByID(id uint64) (*models.Project, error)
-	List() ([]models.Project, error)
-	UpdateStatus(id uint64, status models.ProjectStatus, updatedBy uint64) error
-	Delete(id uint64) error
+	FindByID(orgID, id uint64) (*models.Project, error)
+	Exists(orgID, id uint64) (bool, error)
+	List(filter ProjectFilter) ([]models.Project, error)
+	Count(filter ProjectFilter) (int64, error)
+	UpdateStatus(orgID uint64, change *models.ProjectStatusChange, guard CompletionGuard) (bool, error)
+	ListStatusHistory(orgID, id uint64) ([]models.ProjectStatusChange, error)
+	UpdateDetails(orgID uint64, project *models.Project, version int) (bool, error)
+	Archive(orgID, id, archivedBy uint64) (bool, error)
+	Unarchive(orgID, id, updatedBy uint64) (bool, error)
+	Delete(orgID, id, deletedBy uint64) (bool, error)
+	IsArchived(orgID, id uint64) (bool, error)
+	ListDeleted(orgID uint64) ([]models.Project, error)
+	Restore(orgID, id uint64) (bool, error)
+	PurgeDeleted(before time.Time) (int64, error)
 }
 
 type projectRepository struct {
@@ -25,9 +124,9 @@ func (r *projectRepository) Create(project *models.Project) error {
 	return r.db.Create(project).Error
 }
 
-func (r *projectRepository) FindByID(id uint64) (*models.Project, error) {
+func (r *projectRepository) FindByID(orgID, id uint64) (*models.Project, error) {
 	var project models.Project
-	err := r.db.
+	err := r.db.Scopes(inOrganization("projects", orgID)).
 		Preload("Creator").
 		Preload("LastUpdater").
 		Preload("RFIs.Answerer").
@@ -43,19 +142,291 @@ func (r *projectRepository) FindByID(id uint64) (*models.Project, error) {
 	return &project, nil
 }
 
-func (r *projectRepository) List() ([]models.Project, error) {
+func (r *projectRepository) Exists(orgID, id uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Project{}).
+		Scopes(inOrganization("projects", orgID)).
+		Where("project_id = ?", id).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// IsArchived reports whether the organization's project is archived.
+func (r *projectRepository) IsArchived(orgID, id uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Project{}).
+		Scopes(inOrganization("projects", orgID)).
+		Where("project_id = ? AND archived_at IS NOT NULL", id).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// List returns the projects matching the filter in its sort order, starting
+// after the filter's cursor.
+func (r *projectRepository) List(filter ProjectFilter) ([]models.Project, error) {
+	column, ok := projectSortColumns[filter.Sort]
+	if !ok {
+		column = projectSortColumns[ProjectSortCreatedAt]
+	}
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := r.db.Model(&models.Project{}).Scopes(filterProjects(filter))
+	if filter.After != nil {
+		var value interface{} = filter.After.Value
+		if filter.Sort.isTime() {
+			t, err := time.Parse(time.RFC3339Nano, filter.After.Value)
+			if err != nil {
+				return nil, utils.ErrInvalidCursor
+			}
+			value = t
+		}
+		query = query.Where("("+column+", projects.project_id) "+comparison+" (?, ?)", value, filter.After.ID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var projects []models.Project
+	err := query.Preload("Creator").Preload("LastUpdater").
+		Order(column + " " + direction).
+		Order("projects.project_id " + direction).
+		Find(&projects).Error
+	return projects, err
+}
+
+// Count returns how many projects match the filter, ignoring its cursor and
+// limit.
+func (r *projectRepository) Count(filter ProjectFilter) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Project{}).Scopes(filterProjects(filter)).Count(&count).Error
+	return count, err
+}
+
+func filterProjects(filter ProjectFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(inOrganization("projects", filter.OrganizationID))
+		if filter.MemberID != nil {
+			memberships := db.Session(&gorm.Session{NewDB: true}).
+				Model(&models.ProjectMember{}).
+				Select("project_id").
+				Where("user_id = ?", *filter.MemberID)
+			db = db.Where("projects.project_id IN (?)", memberships)
+		}
+		if len(filter.Statuses) > 0 {
+			db = db.Where("projects.project_status IN ?", filter.Statuses)
+		}
+		if len(filter.Types) > 0 {
+			db = db.Where("projects.project_type IN ?", filter.Types)
+		}
+		if company := strings.TrimSpace(filter.Company); company != "" {
+			db = db.Where("LOWER(projects.company_name) = LOWER(?)", company)
+		}
+		if filter.CreatedBy != nil {
+			db = db.Where("projects.created_by = ?", *filter.CreatedBy)
+		}
+		if filter.CreatedFrom != nil {
+			db = db.Where("projects.created_at >= ?", *filter.CreatedFrom)
+		}
+		if filter.CreatedTo != nil {
+			db = db.Where("projects.created_at < ?", *filter.CreatedTo)
+		}
+		if filter.UpdatedFrom != nil {
+			db = db.Where("projects.updated_at >= ?", *filter.UpdatedFrom)
+		}
+		if filter.UpdatedTo != nil {
+			db = db.Where("projects.updated_at < ?", *filter.UpdatedTo)
+		}
+		switch filter.Archived {
+		case ArchivedExclude:
+			db = db.Where("projects.archived_at IS NULL")
+		case ArchivedOnly:
+			db = db.Where("projects.archived_at IS NOT NULL")
+		}
+		if search := strings.TrimSpace(filter.Search); search != "" {
+			pattern := "%" + utils.EscapeLike(search) + "%"
+			db = db.Where("(projects.project_name ILIKE ? OR projects.company_name ILIKE ? OR projects.company_address ILIKE ?)",
+				pattern, pattern, pattern)
+		}
+		return db
+	}
+}
+
+// CompletionGuard lists what a project needs before it can be completed.
+type CompletionGuard struct {
+	RequireSpecification bool
+	RequireAnsweredRFIs  bool
+}
+
+// UpdateStatus moves the project to the change's new status and records the
+// change, in a single transaction. It reports false without changing anything
+// when the project is no longer in the change's old status. Completing a
+// project checks the guard in the same transaction.
+func (r *projectRepository) UpdateStatus(orgID uint64, change *models.ProjectStatusChange, guard CompletionGuard) (bool, error) {
+	updated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if change.ToStatus == models.StatusCompleted {
+			if err := checkCompletable(tx, orgID, change.ProjectID, guard); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(&models.Project{}).Scopes(inOrganization("projects", orgID)).
+			Where("project_id = ? AND project_status = ?", change.ProjectID, change.FromStatus).
+			Updates(map[string]interface{}{
+				"project_status":  change.ToStatus,
+				"last_updated_by": change.ChangedBy,
+				"version":         gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		updated = true
+		return tx.Create(change).Error
+	})
+	return updated, err
+}
+
+// checkCompletable applies the guard to the project. It locks the project row
+// until the transaction ends, which holds off specifications and RFIs being
+// added to it while the guard's answer is still relied on.
+func checkCompletable(tx *gorm.DB, orgID, projectID uint64, guard CompletionGuard) error {
+	var locked []uint64
+	err := tx.Model(&models.Project{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(inOrganization("projects", orgID)).
+		Where("project_id = ?", projectID).
+		Pluck("project_id", &locked).Error
+	if err != nil {
+		return err
+	}
+
+	if guard.RequireSpecification {
+		var specifications int64
+		if err := tx.Model(&models.ProjectSpecification{}).Where("project_id = ?", projectID).Count(&specifications).Error; err != nil {
+			return err
+		}
+		if specifications == 0 {
+			return errors.New("project cannot be completed without a specification")
+		}
+	}
+
+	if guard.RequireAnsweredRFIs {
+		var unanswered int64
+		err := tx.Model(&models.ProjectRFI{}).Where("project_id = ? AND answer_value IS NULL", projectID).Count(&unanswered).Error
+		if err != nil {
+			return err
+		}
+		if unanswered > 0 {
+			return fmt.Errorf("project cannot be completed while %d RFIs are unanswered", unanswered)
+		}
+	}
+	return nil
+}
+
+// ListStatusHistory returns the project's status changes, oldest first.
+func (r *projectRepository) ListStatusHistory(orgID, id uint64) ([]models.ProjectStatusChange, error) {
+	var history []models.ProjectStatusChange
+	err := r.db.Scopes(projectInOrganization(orgID)).Where("project_id = ?", id).
+		Preload("Changer").
+		Order("changed_at, history_id").
+		Find(&history).Error
+	return history, err
+}
+
+// UpdateDetails saves the project's name, company, address and type if the
+// stored project is still at the given version, and moves it to the next
+// version. It reports false when someone else changed the project first.
+func (r *projectRepository) UpdateDetails(orgID uint64, project *models.Project, version int) (bool, error) {
+	result := r.db.Model(&models.Project{}).Scopes(inOrganization("projects", orgID)).
+		Where("project_id = ? AND version = ?", project.ProjectID, version).
+		Updates(map[string]interface{}{
+			"project_name":    project.ProjectName,
+			"company_name":    project.CompanyName,
+			"company_address": project.CompanyAddress,
+			"project_type":    project.ProjectType,
+			"last_updated_by": project.LastUpdatedBy,
+			"version":         gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Archive hides a completed project from default listings. It reports false
+// when the project is not completed or already archived.
+func (r *projectRepository) Archive(orgID, id, archivedBy uint64) (bool, error) {
+	result := r.db.Model(&models.Project{}).Scopes(inOrganization("projects", orgID)).
+		Where("project_id = ? AND project_status = ? AND archived_at IS NULL", id, models.StatusCompleted).
+		Updates(map[string]interface{}{
+			"archived_at":     time.Now(),
+			"archived_by":     archivedBy,
+			"last_updated_by": archivedBy,
+			"version":         gorm.Expr("version + 1"),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Unarchive brings an archived project back into default listings. It reports
+// false when the project is not archived.
+func (r *projectRepository) Unarchive(orgID, id, updatedBy uint64) (bool, error) {
+	result := r.db.Model(&models.Project{}).Scopes(inOrganization("projects", orgID)).
+		Where("project_id = ? AND archived_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"archived_at":     nil,
+			"archived_by":     nil,
+			"last_updated_by": updatedBy,
+			"version":         gorm.Expr("version + 1"),
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Delete soft-deletes the project. It disappears from every query but keeps
+// its specifications and RFIs until it is restored or purged.
+func (r *projectRepository) Delete(orgID, id, deletedBy uint64) (bool, error) {
+	result := r.db.Model(&models.Project{}).Scopes(inOrganization("projects", orgID)).
+		Where("project_id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at": time.Now(),
+			"deleted_by": deletedBy,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// ListDeleted returns the organization's soft-deleted projects, most recently
+// deleted first.
+func (r *projectRepository) ListDeleted(orgID uint64) ([]models.Project, error) {
 	var projects []models.Project
-	err := r.db.Preload("Creator").Preload("LastUpdater").Find(&projects).Error
+	err := r.db.Unscoped().Scopes(inOrganization("projects", orgID)).
+		Where("projects.deleted_at IS NOT NULL").
+		Preload("Creator").Preload("Deleter").
+		Order("projects.deleted_at DESC").
+		Find(&projects).Error
 	return projects, err
 }
 
-func (r *projectRepository) UpdateStatus(id uint64, status models.ProjectStatus, updatedBy uint64) error {
-	return r.db.Model(&models.Project{}).Where("project_id = ?", id).Updates(map[string]interface{}{
-		"project_status": status,
-		"last_updated_by": updatedBy,
-	}).Error
+// Restore undoes the soft deletion of a project. It reports false when the
+// project is not deleted.
+func (r *projectRepository) Restore(orgID, id uint64) (bool, error) {
+	result := r.db.Unscoped().Model(&models.Project{}).Scopes(inOrganization("projects", orgID)).
+		Where("project_id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"deleted_by": nil,
+		})
+	return result.RowsAffected == 1, result.Error
 }
 
-func (r *projectRepository) Delete(id uint64) error {
-	return r.db.Delete(&models.Project{}, id).Error
+// PurgeDeleted permanently deletes projects of every organization that were
+// soft-deleted before the given time, cascading to their specifications, RFIs,
+// members and status history.
+func (r *projectRepository) PurgeDeleted(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("deleted_at < ?", before).Delete(&models.Project{})
+	return result.RowsAffected, result.Error
 }
\ No newline at end of file
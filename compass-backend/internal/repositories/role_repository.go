@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	Create(role *models.Role) error
+	FindByID(id uint64) (*models.Role, error)
+	FindByName(name models.UserRole) (*models.Role, error)
+	List() ([]models.Role, error)
+	Update(role *models.Role) error
+	Delete(id uint64) error
+	CountUsers(name models.UserRole) (int64, error)
+	ListPermissions() ([]models.PermissionDefinition, error)
+	PermissionsForRole(name models.UserRole) ([]models.Permission, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// Create stores the role together with its permissions.
+func (r *roleRepository) Create(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		return replaceRolePermissions(tx, role.RoleID, role.Permissions)
+	})
+}
+
+func (r *roleRepository) FindByID(id uint64) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.First(&role, id).Error; err != nil {
+		return nil, err
+	}
+	if err := r.loadPermissions(&role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) FindByName(name models.UserRole) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	if err := r.loadPermissions(&role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *roleRepository) List() ([]models.Role, error) {
+	var roles []models.Role
+	if err := r.db.Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		if err := r.loadPermissions(&roles[i]); err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// Update saves the description and replaces the role's permissions.
+func (r *roleRepository) Update(role *models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(role).Updates(map[string]interface{}{
+			"description": role.Description,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return replaceRolePermissions(tx, role.RoleID, role.Permissions)
+	})
+}
+
+func (r *roleRepository) Delete(id uint64) error {
+	return r.db.Delete(&models.Role{}, id).Error
+}
+
+func (r *roleRepository) CountUsers(name models.UserRole) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
+
+func (r *roleRepository) ListPermissions() ([]models.PermissionDefinition, error) {
+	var permissions []models.PermissionDefinition
+	err := r.db.Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *roleRepository) PermissionsForRole(name models.UserRole) ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.db.Model(&models.RolePermission{}).
+		Joins("JOIN roles ON roles.role_id = role_permissions.role_id").
+		Where("roles.name = ?", name).
+		Pluck("role_permissions.permission_name", &permissions).Error
+	return permissions, err
+}
+
+func (r *roleRepository) loadPermissions(role *models.Role) error {
+	role.Permissions = []models.Permission{}
+	return r.db.Model(&models.RolePermission{}).
+		Where("role_id = ?", role.RoleID).
+		Order("permission_name").
+		Pluck("permission_name", &role.Permissions).Error
+}
+
+func replaceRolePermissions(tx *gorm.DB, roleID uint64, permissions []models.Permission) error {
+	if err := tx.Where("role_id = ?", roleID).Delete(&models.RolePermission{}).Error; err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		if err := tx.Create(&models.RolePermission{RoleID: roleID, PermissionName: p}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
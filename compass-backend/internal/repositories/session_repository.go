@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	Create(session *models.Session) error
+	FindByID(sessionID string) (*models.Session, error)
+	ListActiveByUser(userID uint64) ([]models.Session, error)
+	Touch(sessionID, ip string, at time.Time, expiresAt *time.Time) error
+	Revoke(userID uint64, sessionID string) (bool, error)
+	RevokeAllForUser(userID uint64) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *sessionRepository) FindByID(sessionID string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.Where("session_id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) ListActiveByUser(userID uint64) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Touch records activity on a session. The expiry is only moved when a new
+// refresh token was issued for it.
+func (r *sessionRepository) Touch(sessionID, ip string, at time.Time, expiresAt *time.Time) error {
+	updates := map[string]interface{}{
+		"last_seen_at": at,
+		"ip_address":   ip,
+	}
+	if expiresAt != nil {
+		updates["expires_at"] = *expiresAt
+	}
+	return r.db.Model(&models.Session{}).Where("session_id = ?", sessionID).Updates(updates).Error
+}
+
+// Revoke ends one of the user's sessions. It reports false when the session
+// does not belong to the user or has already ended.
+func (r *sessionRepository) Revoke(userID uint64, sessionID string) (bool, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("session_id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *sessionRepository) RevokeAllForUser(userID uint64) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
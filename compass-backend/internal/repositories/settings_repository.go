@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SettingsRepository interface {
+	Get(orgID uint64, key string) (*models.AppSetting, error)
+	Set(orgID uint64, key, value string, updatedBy uint64) error
+}
+
+type settingsRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingsRepository(db *gorm.DB) SettingsRepository {
+	return &settingsRepository{db: db}
+}
+
+func (r *settingsRepository) Get(orgID uint64, key string) (*models.AppSetting, error) {
+	var setting models.AppSetting
+	err := r.db.Scopes(inOrganization("app_settings", orgID)).Where("setting_key = ?", key).First(&setting).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *settingsRepository) Set(orgID uint64, key, value string, updatedBy uint64) error {
+	setting := &models.AppSetting{
+		OrganizationID: orgID,
+		SettingKey:     key,
+		SettingValue:   value,
+		UpdatedBy:      &updatedBy,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "setting_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"setting_value", "updated_by", "updated_at"}),
+	}).Create(setting).Error
+}
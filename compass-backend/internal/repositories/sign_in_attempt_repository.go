@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type SignInAttemptFilter struct {
+	OrganizationID uint64
+	Email          string
+	UserID         *uint64
+	IP             string
+	Outcome        models.SignInOutcome
+	Since          *time.Time
+	Limit          int
+}
+
+type SignInAttemptRepository interface {
+	Create(attempt *models.SignInAttempt) error
+	List(filter SignInAttemptFilter) ([]models.SignInAttempt, error)
+	FailureStatsByIP(ip string, since time.Time) (count int64, last time.Time, err error)
+}
+
+type signInAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewSignInAttemptRepository(db *gorm.DB) SignInAttemptRepository {
+	return &signInAttemptRepository{db: db}
+}
+
+func (r *signInAttemptRepository) Create(attempt *models.SignInAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *signInAttemptRepository) List(filter SignInAttemptFilter) ([]models.SignInAttempt, error) {
+	query := r.db.Model(&models.SignInAttempt{}).Scopes(inOrganization("sign_in_attempts", filter.OrganizationID))
+	if filter.Email != "" {
+		query = query.Where("email = ?", filter.Email)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.IP != "" {
+		query = query.Where("ip_address = ?", filter.IP)
+	}
+	if filter.Outcome != "" {
+		query = query.Where("outcome = ?", filter.Outcome)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+
+	var attempts []models.SignInAttempt
+	err := query.Order("created_at DESC").Limit(filter.Limit).Find(&attempts).Error
+	return attempts, err
+}
+
+// FailureStatsByIP counts failed attempts from an IP since the given time and
+// returns when the most recent one happened.
+func (r *signInAttemptRepository) FailureStatsByIP(ip string, since time.Time) (int64, time.Time, error) {
+	var stats struct {
+		Count int64
+		Last  *time.Time
+	}
+	err := r.db.Model(&models.SignInAttempt{}).
+		Select("COUNT(*) AS count, MAX(created_at) AS last").
+		Where("ip_address = ? AND created_at >= ? AND outcome IN ?", ip, since,
+			[]models.SignInOutcome{models.OutcomeInvalidCredentials, models.OutcomeInactive, models.OutcomeLocked, models.OutcomeMFAFailed}).
+		Scan(&stats).Error
+	if err != nil || stats.Last == nil {
+		return 0, time.Time{}, err
+	}
+	return stats.Count, *stats.Last, nil
+}
@@ -7,8 +7,8 @@ import (
 
 type SpecificationRepository interface {
 	Create(spec *models.ProjectSpecification) error
-	FindByProjectID(projectID uint64) ([]models.ProjectSpecification, error)
-	FindLatestByProjectID(projectID uint64) (*models.ProjectSpecification, error)
+	FindByProjectID(orgID, projectID uint64) ([]models.ProjectSpecification, error)
+	FindLatestByProjectID(orgID, projectID uint64) (*models.ProjectSpecification, error)
 }
 
 type specificationRepository struct {
@@ -23,18 +23,18 @@ func (r *specificationRepository) Create(spec *models.ProjectSpecification) erro
 	return r.db.Create(spec).Error
 }
 
-func (r *specificationRepository) FindByProjectID(projectID uint64) ([]models.ProjectSpecification, error) {
+func (r *specificationRepository) FindByProjectID(orgID, projectID uint64) ([]models.ProjectSpecification, error) {
 	var specs []models.ProjectSpecification
-	err := r.db.Where("project_id = ?", projectID).
+	err := r.db.Scopes(projectInOrganization(orgID)).Where("project_id = ?", projectID).
 		Preload("Creator").
 		Order("version_no DESC").
 		Find(&specs).Error
 	return specs, err
 }
 
-func (r *specificationRepository) FindLatestByProjectID(projectID uint64) (*models.ProjectSpecification, error) {
+func (r *specificationRepository) FindLatestByProjectID(orgID, projectID uint64) (*models.ProjectSpecification, error) {
 	var spec models.ProjectSpecification
-	err := r.db.Where("project_id = ?", projectID).
+	err := r.db.Scopes(projectInOrganization(orgID)).Where("project_id = ?", projectID).
 		Preload("Creator").
 		Order("version_no DESC").
 		First(&spec).Error
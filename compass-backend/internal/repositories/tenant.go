@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// inOrganization restricts a query on a table with an organization_id column
+// to a single tenant.
+func inOrganization(table string, orgID uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(table+".organization_id = ?", orgID)
+	}
+}
+
+// projectInOrganization restricts a query on a table keyed by project_id to
+// the projects of a single tenant.
+func projectInOrganization(orgID uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		projects := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.Project{}).
+			Select("project_id").
+			Where("organization_id = ?", orgID)
+		return db.Where("project_id IN (?)", projects)
+	}
+}
+
+// anyProjectInOrganization is projectInOrganization including soft-deleted
+// projects, for changes that must also hold if a project is restored.
+func anyProjectInOrganization(orgID uint64) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		projects := db.Session(&gorm.Session{NewDB: true}).Unscoped().
+			Model(&models.Project{}).
+			Select("project_id").
+			Where("organization_id = ?", orgID)
+		return db.Where("project_id IN (?)", projects)
+	}
+}
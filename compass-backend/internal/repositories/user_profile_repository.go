@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"compass-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserProfileRepository interface {
+	SaveAvatar(avatar *models.UserAvatar) error
+	FindAvatar(userID uint64) (*models.UserAvatar, error)
+	DeleteAvatar(userID uint64) error
+	CreateEmailChange(request *models.EmailChangeRequest) error
+	FindEmailChangeByHash(tokenHash string) (*models.EmailChangeRequest, error)
+	ConfirmEmailChange(request *models.EmailChangeRequest) (bool, error)
+	InvalidateEmailChanges(userID uint64) error
+}
+
+// ErrEmailTaken is returned when an address already belongs to another user.
+var ErrEmailTaken = errors.New("email already exists")
+
+type userProfileRepository struct {
+	db *gorm.DB
+}
+
+func NewUserProfileRepository(db *gorm.DB) UserProfileRepository {
+	return &userProfileRepository{db: db}
+}
+
+// SaveAvatar replaces the user's avatar and records when it changed so that
+// clients can tell a cached image is stale.
+func (r *userProfileRepository) SaveAvatar(avatar *models.UserAvatar) error {
+	avatar.UpdatedAt = time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"content_type", "data", "updated_at"}),
+		}).Create(avatar).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("user_id = ?", avatar.UserID).Update("avatar_updated_at", avatar.UpdatedAt).Error
+	})
+}
+
+func (r *userProfileRepository) FindAvatar(userID uint64) (*models.UserAvatar, error) {
+	var avatar models.UserAvatar
+	err := r.db.Where("user_id = ?", userID).First(&avatar).Error
+	if err != nil {
+		return nil, err
+	}
+	return &avatar, nil
+}
+
+func (r *userProfileRepository) DeleteAvatar(userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserAvatar{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("user_id = ?", userID).Update("avatar_updated_at", nil).Error
+	})
+}
+
+func (r *userProfileRepository) CreateEmailChange(request *models.EmailChangeRequest) error {
+	return r.db.Create(request).Error
+}
+
+func (r *userProfileRepository) FindEmailChangeByHash(tokenHash string) (*models.EmailChangeRequest, error) {
+	var request models.EmailChangeRequest
+	err := r.db.Where("token_hash = ?", tokenHash).First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ConfirmEmailChange consumes an email change token and moves its user to the
+// new address, in a single transaction. It reports false without changing
+// anything when the token was already used or has expired, or the user no
+// longer exists, and returns ErrEmailTaken when another user has the address
+// by now.
+func (r *userProfileRepository) ConfirmEmailChange(request *models.EmailChangeRequest) (bool, error) {
+	confirmed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.EmailChangeRequest{}).
+			Where("request_id = ? AND used_at IS NULL AND expires_at > ?", request.RequestID, now).
+			Update("used_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		var taken int64
+		err := tx.Model(&models.User{}).
+			Where("LOWER(email) = LOWER(?) AND user_id <> ?", request.NewEmail, request.UserID).
+			Count(&taken).Error
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			return ErrEmailTaken
+		}
+
+		result = tx.Model(&models.User{}).Where("user_id = ?", request.UserID).Update("email", request.NewEmail)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Keep the token unused
+			return gorm.ErrRecordNotFound
+		}
+
+		confirmed = true
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return confirmed, err
+}
+
+func (r *userProfileRepository) InvalidateEmailChanges(userID uint64) error {
+	return r.db.Model(&models.EmailChangeRequest{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
@@ -1,17 +1,94 @@
 package repositories
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"compass-backend/internal/models"
+	"compass-backend/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrLastAdmin is returned when a change would leave an organization without
+// an active admin.
+var ErrLastAdmin = errors.New("the organization must keep at least one active admin")
+
+type UserSort string
+
+const (
+	UserSortName      UserSort = "name"
+	UserSortEmail     UserSort = "email"
+	UserSortCreatedAt UserSort = "created_at"
 )
 
+// userSortColumns maps each sort to the expression users are ordered by.
+var userSortColumns = map[UserSort]string{
+	UserSortName:      "LOWER(users.full_name)",
+	UserSortEmail:     "LOWER(users.email)",
+	UserSortCreatedAt: "users.created_at",
+}
+
+// IsValid reports whether the sort is one users can be ordered by.
+func (s UserSort) IsValid() bool {
+	_, ok := userSortColumns[s]
+	return ok
+}
+
+type UserFilter struct {
+	OrganizationID uint64
+	Search         string
+	Roles          []models.UserRole
+	Statuses       []models.AccountStatus
+	Sort           UserSort
+	Descending     bool
+	After          *UserCursor
+	Limit          int
+}
+
+// UserCursor is the position of a user in the order of a filter. Users are
+// ordered by the sort value and then by ID, so positions are unique.
+type UserCursor struct {
+	Sort       UserSort `json:"s"`
+	Descending bool     `json:"d,omitempty"`
+	Value      string   `json:"v"`
+	ID         uint64   `json:"id"`
+}
+
+// CursorFor returns the position of user in the filter's order.
+func (f UserFilter) CursorFor(user *models.User) UserCursor {
+	cursor := UserCursor{Sort: f.Sort, Descending: f.Descending, ID: user.UserID}
+	switch f.Sort {
+	case UserSortName:
+		cursor.Value = strings.ToLower(user.FullName)
+	case UserSortEmail:
+		cursor.Value = strings.ToLower(user.Email)
+	case UserSortCreatedAt:
+		cursor.Value = user.CreatedAt.Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
 type UserRepository interface {
 	Create(user *models.User) error
 	FindByID(id uint64) (*models.User, error)
+	FindInOrganization(orgID, id uint64) (*models.User, error)
 	FindByEmail(email string) (*models.User, error)
 	UpdateStatus(id uint64, status models.AccountStatus) error
 	UpdatePassword(id uint64, hashedPassword string) error
-	List() ([]models.User, error)
+	ReplacePasswordHash(id uint64, oldHash, newHash string) error
+	IncrementTokenVersion(id uint64) error
+	Activate(id uint64, hashedPassword string) error
+	IncrementLoginFailures(id uint64) (int, error)
+	LockUntil(id uint64, until time.Time) error
+	ResetLoginFailures(id uint64) error
+	UpdateRole(id uint64, role models.UserRole) error
+	UpdateProfile(user *models.User) error
+	CountActiveAdmins(orgID uint64) (int64, error)
+	List(filter UserFilter) ([]models.User, error)
+	Count(filter UserFilter) (int64, error)
+	ListServiceAccounts(orgID uint64) ([]models.User, error)
 }
 
 type userRepository struct {
@@ -35,25 +112,213 @@ func (r *userRepository) FindByID(id uint64) (*models.User, error) {
 	return &user, nil
 }
 
+func (r *userRepository) FindInOrganization(orgID, id uint64) (*models.User, error) {
+	var user models.User
+	err := r.db.Scopes(inOrganization("users", orgID)).Preload("InvitedByUser").First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByEmail matches the address case-insensitively, like the unique index on
+// LOWER(email) that keeps an address from being registered twice in different
+// case.
 func (r *userRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// UpdateStatus changes the account status. It returns ErrLastAdmin instead
+// when that would leave the organization without an active admin.
 func (r *userRepository) UpdateStatus(id uint64, status models.AccountStatus) error {
-	return r.db.Model(&models.User{}).Where("user_id = ?", id).Update("account_status", status).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if status != models.StatusActive {
+			if err := keepActiveAdmin(tx, id); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.User{}).Where("user_id = ?", id).Update("account_status", status).Error
+	})
 }
 
 func (r *userRepository) UpdatePassword(id uint64, hashedPassword string) error {
-	return r.db.Model(&models.User{}).Where("user_id = ?", id).Update("password_hash", hashedPassword).Error
+	return r.db.Model(&models.User{}).Where("user_id = ?", id).Updates(map[string]interface{}{
+		"password_hash":       hashedPassword,
+		"password_changed_at": time.Now(),
+	}).Error
+}
+
+// ReplacePasswordHash swaps a hash for a new hash of the same password. It does
+// nothing if the password was changed in the meantime, and it does not count
+// as a password change.
+func (r *userRepository) ReplacePasswordHash(id uint64, oldHash, newHash string) error {
+	return r.db.Model(&models.User{}).
+		Where("user_id = ? AND password_hash = ?", id, oldHash).
+		Update("password_hash", newHash).Error
+}
+
+func (r *userRepository) IncrementTokenVersion(id uint64) error {
+	return r.db.Model(&models.User{}).Where("user_id = ?", id).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+func (r *userRepository) Activate(id uint64, hashedPassword string) error {
+	return r.db.Model(&models.User{}).Where("user_id = ?", id).Updates(map[string]interface{}{
+		"password_hash":       hashedPassword,
+		"password_changed_at": time.Now(),
+		"account_status":      models.StatusActive,
+	}).Error
+}
+
+// IncrementLoginFailures counts one more failed sign-in in a single statement,
+// so that concurrent failures are all counted, and returns the new count.
+func (r *userRepository) IncrementLoginFailures(id uint64) (int, error) {
+	var attempts int
+	err := r.db.Raw("UPDATE users SET failed_login_attempts = failed_login_attempts + 1 WHERE user_id = ? RETURNING failed_login_attempts", id).
+		Scan(&attempts).Error
+	return attempts, err
+}
+
+// LockUntil locks the account until the given time. A later lock already in
+// place is kept, so that concurrent failures cannot shorten it.
+func (r *userRepository) LockUntil(id uint64, until time.Time) error {
+	return r.db.Model(&models.User{}).Where("user_id = ?", id).
+		Update("locked_until", gorm.Expr("GREATEST(COALESCE(locked_until, ?), ?)", until, until)).Error
+}
+
+func (r *userRepository) ResetLoginFailures(id uint64) error {
+	return r.db.Model(&models.User{}).Where("user_id = ?", id).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"locked_until":          nil,
+	}).Error
+}
+
+// UpdateRole changes the user's role. It returns ErrLastAdmin instead when
+// that would leave the organization without an active admin.
+func (r *userRepository) UpdateRole(id uint64, role models.UserRole) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if role != models.RoleAdmin {
+			if err := keepActiveAdmin(tx, id); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.User{}).Where("user_id = ?", id).Update("role", role).Error
+	})
+}
+
+// UpdateProfile saves the fields a user edits on their profile.
+func (r *userRepository) UpdateProfile(user *models.User) error {
+	return r.db.Model(&models.User{}).Where("user_id = ?", user.UserID).Updates(map[string]interface{}{
+		"full_name":              user.FullName,
+		"timezone":               user.Timezone,
+		"notify_rfi_updates":     user.NotificationPrefs.RFIUpdates,
+		"notify_project_updates": user.NotificationPrefs.ProjectUpdates,
+		"notify_weekly_digest":   user.NotificationPrefs.WeeklyDigest,
+	}).Error
+}
+
+// CountActiveAdmins counts the organization's active users with the admin
+// role.
+func (r *userRepository) CountActiveAdmins(orgID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Scopes(inOrganization("users", orgID)).
+		Where("role = ? AND account_status = ? AND is_service_account = ?", models.RoleAdmin, models.StatusActive, false).
+		Count(&count).Error
+	return count, err
+}
+
+// keepActiveAdmin returns ErrLastAdmin if the user is the only active admin of
+// their organization. It locks the organization's active admins until the
+// transaction ends, so that concurrent changes cannot each take away a
+// different admin and leave none.
+func keepActiveAdmin(tx *gorm.DB, userID uint64) error {
+	organization := tx.Session(&gorm.Session{NewDB: true}).
+		Model(&models.User{}).Select("organization_id").Where("user_id = ?", userID)
+
+	var adminIDs []uint64
+	err := tx.Model(&models.User{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("organization_id = (?) AND role = ? AND account_status = ? AND is_service_account = ?",
+			organization, models.RoleAdmin, models.StatusActive, false).
+		Order("user_id").
+		Pluck("user_id", &adminIDs).Error
+	if err != nil {
+		return err
+	}
+
+	if len(adminIDs) == 1 && adminIDs[0] == userID {
+		return ErrLastAdmin
+	}
+	return nil
+}
+
+// List returns the users matching the filter in its sort order, starting after
+// the filter's cursor.
+func (r *userRepository) List(filter UserFilter) ([]models.User, error) {
+	column, ok := userSortColumns[filter.Sort]
+	if !ok {
+		column = userSortColumns[UserSortName]
+	}
+	direction, comparison := "ASC", ">"
+	if filter.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := r.db.Model(&models.User{}).Scopes(filterUsers(filter))
+	if filter.After != nil {
+		var value interface{} = filter.After.Value
+		if filter.Sort == UserSortCreatedAt {
+			createdAt, err := time.Parse(time.RFC3339Nano, filter.After.Value)
+			if err != nil {
+				return nil, utils.ErrInvalidCursor
+			}
+			value = createdAt
+		}
+		query = query.Where("("+column+", users.user_id) "+comparison+" (?, ?)", value, filter.After.ID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var users []models.User
+	err := query.Preload("InvitedByUser").
+		Order(column + " " + direction).
+		Order("users.user_id " + direction).
+		Find(&users).Error
+	return users, err
+}
+
+// Count returns how many users match the filter, ignoring its cursor and
+// limit.
+func (r *userRepository) Count(filter UserFilter) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Scopes(filterUsers(filter)).Count(&count).Error
+	return count, err
+}
+
+func filterUsers(filter UserFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Scopes(inOrganization("users", filter.OrganizationID))
+		if search := strings.TrimSpace(filter.Search); search != "" {
+			pattern := "%" + utils.EscapeLike(search) + "%"
+			db = db.Where("(users.full_name ILIKE ? OR users.email ILIKE ?)", pattern, pattern)
+		}
+		if len(filter.Roles) > 0 {
+			db = db.Where("users.role IN ?", filter.Roles)
+		}
+		if len(filter.Statuses) > 0 {
+			db = db.Where("users.account_status IN ?", filter.Statuses)
+		}
+		return db
+	}
 }
 
-func (r *userRepository) List() ([]models.User, error) {
+func (r *userRepository) ListServiceAccounts(orgID uint64) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Preload("InvitedByUser").Find(&users).Error
+	err := r.db.Scopes(inOrganization("users", orgID)).Where("is_service_account = ?", true).Find(&users).Error
 	return users, err
 }
\ No newline at end of file
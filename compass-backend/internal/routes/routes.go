@@ -1,9 +1,15 @@
 package routes
 
 import (
+	"log"
+
 	"compass-backend/config"
+	"compass-backend/internal/breach"
 	"compass-backend/internal/controllers"
+	"compass-backend/internal/mailer"
 	"compass-backend/internal/middleware"
+	"compass-backend/internal/models"
+	"compass-backend/internal/oidc"
 	"compass-backend/internal/repositories"
 	"compass-backend/internal/services"
 
@@ -17,70 +23,251 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	projectRepo := repositories.NewProjectRepository(db)
 	specRepo := repositories.NewSpecificationRepository(db)
 	rfiRepo := repositories.NewRFIRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	inviteRepo := repositories.NewInviteRepository(db)
+	passwordResetRepo := repositories.NewPasswordResetRepository(db)
+	mfaRepo := repositories.NewMFARepository(db)
+	settingsRepo := repositories.NewSettingsRepository(db)
+	signInAttemptRepo := repositories.NewSignInAttemptRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	projectMemberRepo := repositories.NewProjectMemberRepository(db)
+	orgRepo := repositories.NewOrganizationRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	oidcRepo := repositories.NewOIDCRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	passwordHistoryRepo := repositories.NewPasswordHistoryRepository(db)
+	impersonationRepo := repositories.NewImpersonationRepository(db)
+	userProfileRepo := repositories.NewUserProfileRepository(db)
+	offboardingRepo := repositories.NewOffboardingRepository(db)
+	personalDataRepo := repositories.NewPersonalDataRepository(db)
+
+	// Initialize mailer
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
+	// Initialize breached password list
+	breachedPasswords, err := breach.NewChecker(cfg.Password.BreachedListDir)
+	if err != nil {
+		log.Fatalf("Failed to open breached password list: %v", err)
+	}
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo, cfg)
-	userService := services.NewUserService(userRepo)
-	projectService := services.NewProjectService(projectRepo, specRepo, rfiRepo)
-	specService := services.NewSpecificationService(specRepo)
-	rfiService := services.NewRFIService(rfiRepo)
+	authStateService := services.NewAuthStateService(userRepo, refreshTokenRepo, sessionRepo, roleRepo)
+	settingsService := services.NewSettingsService(settingsRepo)
+	passwordPolicy := services.NewPasswordPolicy(passwordHistoryRepo, breachedPasswords, cfg)
+	mfaService := services.NewMFAService(mfaRepo, userRepo, settingsService, cfg)
+	sessionService := services.NewSessionService(sessionRepo, refreshTokenRepo, userRepo, cfg)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, signInAttemptRepo, mfaService, sessionService, passwordPolicy, cfg)
+	inviteService := services.NewInviteService(inviteRepo, userRepo, passwordPolicy, mail, cfg)
+	roleService := services.NewRoleService(roleRepo, userRepo, authStateService)
+	userService := services.NewUserService(userRepo, roleRepo, roleService, authStateService, inviteService, passwordPolicy)
+	passwordResetService := services.NewPasswordResetService(passwordResetRepo, userRepo, authStateService, passwordPolicy, mail, cfg)
+	orgService := services.NewOrganizationService(orgRepo, userService)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, userRepo, roleRepo, authStateService)
+	impersonationService := services.NewImpersonationService(impersonationRepo, userRepo, authStateService, cfg)
+	profileService := services.NewProfileService(userRepo, userProfileRepo, authStateService, mail, cfg)
+	offboardingService := services.NewOffboardingService(offboardingRepo, userRepo, authStateService)
+	personalDataService := services.NewPersonalDataService(personalDataRepo, userRepo, authStateService)
+	projectAccess := services.NewProjectAccess(projectRepo, projectMemberRepo)
+	projectService := services.NewProjectService(projectRepo, specRepo, rfiRepo, projectMemberRepo, userRepo, projectAccess, cfg)
+	specService := services.NewSpecificationService(specRepo, projectAccess)
+	rfiService := services.NewRFIService(rfiRepo, projectAccess)
 
 	// Initialize controllers
 	authController := controllers.NewAuthController(authService)
-	userController := controllers.NewUserController(userService)
+	userController := controllers.NewUserController(userService, offboardingService)
+	inviteController := controllers.NewInviteController(inviteService)
+	passwordResetController := controllers.NewPasswordResetController(passwordResetService)
+	mfaController := controllers.NewMFAController(mfaService)
+	sessionController := controllers.NewSessionController(sessionService)
+	settingsController := controllers.NewSettingsController(settingsService)
+	roleController := controllers.NewRoleController(roleService)
+	orgController := controllers.NewOrganizationController(orgService)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
+	impersonationController := controllers.NewImpersonationController(impersonationService)
+	profileController := controllers.NewProfileController(profileService, cfg)
+	personalDataController := controllers.NewPersonalDataController(personalDataService)
 	projectController := controllers.NewProjectController(projectService)
 	specController := controllers.NewSpecificationController(specService)
 	rfiController := controllers.NewRFIController(rfiService)
+	jwksController := controllers.NewJWKSController(cfg)
 
 	// Public routes
+	router.GET("/.well-known/jwks.json", jwksController.GetJWKS)
+
 	auth := router.Group("/auth")
 	{
 		auth.POST("/signin", authController.SignIn)
+		auth.POST("/mfa/enroll", authController.BeginMFAEnrollment)
+		auth.POST("/mfa/verify", authController.VerifyMFA)
 		auth.POST("/refresh", authController.RefreshToken)
+		auth.POST("/password/renew", authController.RenewPassword)
+		auth.POST("/accept-invite", inviteController.AcceptInvite)
+		auth.POST("/forgot-password", passwordResetController.ForgotPassword)
+		auth.POST("/reset-password", passwordResetController.ResetPassword)
+		auth.POST("/confirm-email", profileController.ConfirmEmailChange)
+	}
+
+	// Single sign-on, when an identity provider is configured
+	if cfg.OIDC.IssuerURL != "" {
+		if cfg.OIDC.AutoProvision {
+			if _, err := roleRepo.FindByName(models.UserRole(cfg.OIDC.ProvisionRole)); err != nil {
+				log.Fatalf("OIDC_PROVISION_ROLE %q is not a known role: %v", cfg.OIDC.ProvisionRole, err)
+			}
+		}
+		provider := oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDC.IssuerURL,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		}, nil)
+		ssoService := services.NewSSOService(provider, oidcRepo, userRepo, orgRepo, authService, cfg)
+		ssoController := controllers.NewSSOController(ssoService)
+
+		auth.GET("/oidc/login", ssoController.Login)
+		auth.POST("/oidc/callback", ssoController.Callback)
 	}
 
 	// Protected routes
 	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware(cfg))
+	api.Use(middleware.AuthMiddleware(cfg, authStateService, sessionService, apiKeyService, impersonationService))
 	{
 		// Logout
-		api.POST("/auth/logout", authController.Logout)
+		api.POST("/auth/logout", middleware.BlockImpersonation(), authController.Logout)
+
+		// Sign-in audit
+		api.GET("/auth/sign-in-attempts", middleware.RequirePermission(models.PermAuditView), authController.ListSignInAttempts)
 
 		// User routes
 		users := api.Group("/users")
 		{
 			// Change password (authenticated users) - must be before /:id routes
-			users.PATCH("/change-password", userController.ChangePassword)
+			users.PATCH("/change-password", middleware.BlockImpersonation(), userController.ChangePassword)
+
+			// Profile of the current user
+			users.GET("/me", profileController.GetProfile)
+			users.PATCH("/me", profileController.UpdateProfile)
+			users.POST("/me/email", middleware.BlockImpersonation(), profileController.RequestEmailChange)
+			users.PUT("/me/avatar", profileController.UploadAvatar)
+			users.DELETE("/me/avatar", profileController.DeleteAvatar)
+
+			// Two-factor authentication for the current user
+			users.POST("/me/mfa/enroll", middleware.BlockImpersonation(), mfaController.BeginEnrollment)
+			users.POST("/me/mfa/confirm", middleware.BlockImpersonation(), mfaController.ConfirmEnrollment)
+			users.POST("/me/mfa/recovery-codes", middleware.BlockImpersonation(), mfaController.RegenerateRecoveryCodes)
+			users.DELETE("/me/mfa", middleware.BlockImpersonation(), mfaController.Disable)
+
+			// Sessions of the current user
+			users.GET("/me/sessions", sessionController.ListMySessions)
+			users.DELETE("/me/sessions", middleware.BlockImpersonation(), sessionController.RevokeAllMySessions)
+			users.DELETE("/me/sessions/:session_id", middleware.BlockImpersonation(), sessionController.RevokeMySession)
 
-			// Admin only routes
-			users.POST("", middleware.AdminOnly(), userController.CreateUser)
-			users.PATCH("/:id/status", middleware.AdminOnly(), userController.UpdateUserStatus)
-			users.PATCH("/:id/reset-password", middleware.AdminOnly(), userController.ResetPassword)
-			users.GET("", middleware.AdminOnly(), userController.ListUsers)
+			// User administration
+			users.POST("", middleware.RequirePermission(models.PermUserManage), userController.CreateUser)
+			users.PATCH("/:id/status", middleware.RequirePermission(models.PermUserManage), userController.UpdateUserStatus)
+			users.PATCH("/:id/reset-password", middleware.RequirePermission(models.PermUserManage), userController.ResetPassword)
+			users.PATCH("/:id/unlock", middleware.RequirePermission(models.PermUserManage), userController.UnlockUser)
+			users.POST("/:id/offboard", middleware.RequirePermission(models.PermUserManage), userController.OffboardUser)
+			users.GET("/:id/personal-data", middleware.RequirePermission(models.PermUserExport), personalDataController.ExportPersonalData)
+			users.POST("/:id/erase", middleware.RequirePermission(models.PermUserErase), personalDataController.EraseUser)
+			users.PATCH("/:id/role", middleware.RequirePermission(models.PermRoleAssign), roleController.AssignRole)
+			users.POST("/:id/invite/resend", middleware.RequirePermission(models.PermUserManage), inviteController.ResendInvite)
+			users.DELETE("/:id/invite", middleware.RequirePermission(models.PermUserManage), inviteController.RevokeInvite)
+			users.GET("/:id/sessions", middleware.RequirePermission(models.PermUserManage), sessionController.ListUserSessions)
+			users.DELETE("/:id/sessions", middleware.RequirePermission(models.PermUserManage), sessionController.RevokeAllUserSessions)
+			users.DELETE("/:id/sessions/:session_id", middleware.RequirePermission(models.PermUserManage), sessionController.RevokeUserSession)
+			users.POST("/:id/impersonate", middleware.BlockImpersonation(), middleware.RequirePermission(models.PermUserImpersonate), impersonationController.StartImpersonation)
+			users.GET("", middleware.RequirePermission(models.PermUserView), userController.ListUsers)
+			users.GET("/export", middleware.RequirePermission(models.PermUserView), userController.ExportUsers)
+			users.GET("/:id", middleware.RequirePermission(models.PermUserView), userController.GetUser)
+			users.PATCH("/:id", middleware.RequirePermission(models.PermUserManage), userController.UpdateUser)
+			users.GET("/:id/avatar", profileController.GetAvatar)
+		}
+
+		// Impersonation of users by admins, and its audit log
+		api.POST("/impersonation/stop", impersonationController.StopImpersonation)
+		api.GET("/impersonations", middleware.RequirePermission(models.PermAuditView), impersonationController.ListImpersonations)
+		api.GET("/impersonations/:id", middleware.RequirePermission(models.PermAuditView), impersonationController.GetImpersonation)
+
+		// Service accounts and their API keys
+		serviceAccounts := api.Group("/service-accounts")
+		serviceAccounts.Use(middleware.RequirePermission(models.PermAPIKeyManage))
+		{
+			// The account's role is granted to whoever holds its keys
+			serviceAccounts.POST("", middleware.RequirePermission(models.PermRoleAssign), apiKeyController.CreateServiceAccount)
+			serviceAccounts.GET("", apiKeyController.ListServiceAccounts)
+			serviceAccounts.POST("/:id/api-keys", apiKeyController.IssueKey)
+			serviceAccounts.GET("/:id/api-keys", apiKeyController.ListKeys)
+			serviceAccounts.DELETE("/:id/api-keys/:key_id", apiKeyController.RevokeKey)
+		}
+
+		// Settings
+		settings := api.Group("/settings")
+		{
+			settings.GET("/security", middleware.RequirePermission(models.PermSettingsManage), settingsController.GetSecurityPolicy)
+			settings.PUT("/security", middleware.RequirePermission(models.PermSettingsManage), settingsController.UpdateSecurityPolicy)
+		}
+
+		// Roles and permissions are shared by every organization; admins can
+		// read and assign them, only super-admins can change them
+		roles := api.Group("/roles")
+		{
+			roles.GET("", middleware.RequireAnyPermission(models.PermRoleManage, models.PermRoleAssign), roleController.ListRoles)
+			roles.POST("", middleware.RequirePermission(models.PermRoleManage), roleController.CreateRole)
+			roles.GET("/:id", middleware.RequireAnyPermission(models.PermRoleManage, models.PermRoleAssign), roleController.GetRole)
+			roles.PUT("/:id", middleware.RequirePermission(models.PermRoleManage), roleController.UpdateRole)
+			roles.DELETE("/:id", middleware.RequirePermission(models.PermRoleManage), roleController.DeleteRole)
+		}
+		api.GET("/permissions", middleware.RequireAnyPermission(models.PermRoleManage, models.PermRoleAssign), roleController.ListPermissions)
+
+		// Organizations (platform super-admins)
+		orgs := api.Group("/organizations")
+		orgs.Use(middleware.RequirePermission(models.PermOrgManage))
+		{
+			orgs.POST("", orgController.CreateOrganization)
+			orgs.GET("", orgController.ListOrganizations)
+			orgs.GET("/:id", orgController.GetOrganization)
+			orgs.POST("/:id/admins", orgController.CreateOrganizationAdmin)
 		}
 
 		// Projects
 		projects := api.Group("/projects")
 		{
-			projects.POST("", projectController.CreateProject)
-			projects.GET("", projectController.ListProjects)
-			projects.GET("/:id", projectController.GetProject)
-			projects.PATCH("/:id/status", projectController.UpdateProjectStatus)
-			projects.DELETE("/:id", middleware.AdminOnly(), projectController.DeleteProject)
+			projects.POST("", middleware.RequirePermission(models.PermProjectCreate), projectController.CreateProject)
+			projects.GET("", middleware.RequirePermission(models.PermProjectView), projectController.ListProjects)
+			projects.GET("/deleted", middleware.RequirePermission(models.PermProjectRestore), projectController.ListDeletedProjects)
+			projects.GET("/:id", middleware.RequirePermission(models.PermProjectView), projectController.GetProject)
+			projects.PATCH("/:id", middleware.RequirePermission(models.PermProjectUpdate), projectController.UpdateProject)
+			projects.POST("/:id/clone", middleware.RequirePermission(models.PermProjectCreate), projectController.CloneProject)
+			projects.PATCH("/:id/status", middleware.RequirePermission(models.PermProjectUpdateStatus), projectController.UpdateProjectStatus)
+			projects.GET("/:id/status-history", middleware.RequirePermission(models.PermProjectView), projectController.GetStatusHistory)
+			projects.DELETE("/:id", middleware.RequirePermission(models.PermProjectDelete), projectController.DeleteProject)
+			projects.POST("/:id/restore", middleware.RequirePermission(models.PermProjectRestore), projectController.RestoreProject)
+			projects.POST("/:id/archive", middleware.RequirePermission(models.PermProjectUpdateStatus), projectController.ArchiveProject)
+			projects.POST("/:id/unarchive", middleware.RequirePermission(models.PermProjectUpdateStatus), projectController.UnarchiveProject)
+
+			// Project members
+			projects.GET("/:id/members", middleware.RequirePermission(models.PermProjectView), projectController.ListMembers)
+			projects.POST("/:id/members", middleware.RequirePermission(models.PermProjectView), projectController.AddMember)
+			projects.PATCH("/:id/members/:user_id", middleware.RequirePermission(models.PermProjectView), projectController.UpdateMember)
+			projects.DELETE("/:id/members/:user_id", middleware.RequirePermission(models.PermProjectView), projectController.RemoveMember)
 
 			// Project specifications
-			projects.POST("/:id/specifications", specController.CreateSpecification)
-			projects.GET("/:id/specifications", specController.GetProjectSpecifications)
+			projects.POST("/:id/specifications", middleware.RequirePermission(models.PermSpecCreate), specController.CreateSpecification)
+			projects.GET("/:id/specifications", middleware.RequirePermission(models.PermSpecView), specController.GetProjectSpecifications)
 
 			// Project RFIs
-			projects.POST("/:id/rfis", rfiController.CreateRFI)
-			projects.GET("/:id/rfis", rfiController.GetProjectRFIs)
+			projects.POST("/:id/rfis", middleware.RequirePermission(models.PermRFICreate), rfiController.CreateRFI)
+			projects.GET("/:id/rfis", middleware.RequirePermission(models.PermRFIView), rfiController.GetProjectRFIs)
 		}
 
 		// RFIs
 		rfis := api.Group("/rfis")
 		{
-			rfis.PATCH("/:id/answer", rfiController.AnswerRFI)
+			rfis.PATCH("/:id/answer", middleware.RequirePermission(models.PermRFIAnswer), rfiController.AnswerRFI)
 		}
 	}
 }
\ No newline at end of file
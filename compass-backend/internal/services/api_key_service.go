@@ -0,0 +1,230 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
+)
+
+const (
+	apiKeyPrefix = "cmp"
+
+	// apiKeyTouchInterval limits how often last_used_at is written for a key
+	// that is used on every request.
+	apiKeyTouchInterval = time.Minute
+)
+
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// IssuedAPIKey is a newly created key. Key is the only time the full secret
+// is available.
+type IssuedAPIKey struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+type APIKeyService interface {
+	CreateServiceAccount(orgID uint64, name string, role models.UserRole, createdBy uint64) (*models.User, error)
+	ListServiceAccounts(orgID uint64) ([]models.User, error)
+	IssueKey(orgID, serviceAccountID uint64, name string, scopes []models.Permission, expiresAt *time.Time, createdBy uint64) (*IssuedAPIKey, error)
+	ListKeys(orgID, serviceAccountID uint64) ([]models.APIKey, error)
+	RevokeKey(orgID, serviceAccountID, keyID uint64) error
+	Authenticate(rawKey string) (*AuthState, *models.APIKey, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo       repositories.APIKeyRepository
+	userRepo         repositories.UserRepository
+	roleRepo         repositories.RoleRepository
+	authStateService AuthStateService
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, userRepo repositories.UserRepository, roleRepo repositories.RoleRepository, authStateService AuthStateService) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo:       apiKeyRepo,
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		authStateService: authStateService,
+	}
+}
+
+// CreateServiceAccount adds a user that cannot sign in interactively and acts
+// only through API keys. Its role bounds what any of its keys can do.
+func (s *apiKeyService) CreateServiceAccount(orgID uint64, name string, role models.UserRole, createdBy uint64) (*models.User, error) {
+	if role == models.RoleSuperAdmin {
+		return nil, errors.New("the super_admin role cannot be assigned to organization users")
+	}
+
+	if _, err := s.roleRepo.FindByName(role); err != nil {
+		return nil, errors.New("role not found")
+	}
+
+	// Users are identified by email, so service accounts get a unique
+	// address on a reserved domain that can never receive mail
+	suffix, err := utils.GenerateRandomToken(6)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.User{
+		OrganizationID:   &orgID,
+		FullName:         name,
+		Email:            strings.ToLower(fmt.Sprintf("svc-%s@service-accounts.invalid", suffix)),
+		Role:             role,
+		AccountStatus:    models.StatusActive,
+		InvitedBy:        &createdBy,
+		IsServiceAccount: true,
+	}
+	if err := s.userRepo.Create(account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *apiKeyService) ListServiceAccounts(orgID uint64) ([]models.User, error) {
+	return s.userRepo.ListServiceAccounts(orgID)
+}
+
+func (s *apiKeyService) IssueKey(orgID, serviceAccountID uint64, name string, scopes []models.Permission, expiresAt *time.Time, createdBy uint64) (*IssuedAPIKey, error) {
+	if _, err := s.findServiceAccount(orgID, serviceAccountID); err != nil {
+		return nil, err
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, errors.New("expiry must be in the future")
+	}
+
+	if err := s.validateScopes(scopes); err != nil {
+		return nil, err
+	}
+
+	prefix, err := utils.GenerateRandomToken(6)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{
+		OrganizationID: orgID,
+		UserID:         serviceAccountID,
+		Name:           name,
+		Prefix:         prefix,
+		SecretHash:     utils.HashToken(secret),
+		Scopes:         scopes,
+		ExpiresAt:      expiresAt,
+		CreatedBy:      createdBy,
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, err
+	}
+
+	return &IssuedAPIKey{
+		Key:    fmt.Sprintf("%s_%s_%s", apiKeyPrefix, prefix, secret),
+		APIKey: key,
+	}, nil
+}
+
+func (s *apiKeyService) ListKeys(orgID, serviceAccountID uint64) ([]models.APIKey, error) {
+	if _, err := s.findServiceAccount(orgID, serviceAccountID); err != nil {
+		return nil, err
+	}
+
+	return s.apiKeyRepo.ListByUser(orgID, serviceAccountID)
+}
+
+func (s *apiKeyService) RevokeKey(orgID, serviceAccountID, keyID uint64) error {
+	revoked, err := s.apiKeyRepo.Revoke(orgID, serviceAccountID, keyID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return errors.New("API key not found")
+	}
+	return nil
+}
+
+// Authenticate resolves a raw key to the state of its service account. The
+// returned permissions are those of the account's role narrowed to the key's
+// scopes.
+func (s *apiKeyService) Authenticate(rawKey string) (*AuthState, *models.APIKey, error) {
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.FindByPrefix(parts[1])
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if subtle.ConstantTimeCompare([]byte(utils.HashToken(parts[2])), []byte(key.SecretHash)) != 1 {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	state, err := s.authStateService.GetState(key.UserID)
+	if err != nil || state.AccountStatus != models.StatusActive || state.OrganizationID != key.OrganizationID {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	scoped := *state
+	scoped.Permissions = make(models.PermissionSet, len(key.Scopes))
+	for _, p := range key.Scopes {
+		if state.Permissions.Has(p) {
+			scoped.Permissions[p] = true
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchLastUsed(key.KeyID, now); err != nil {
+			log.Printf("Failed to record use of API key %s: %v", key.Prefix, err)
+		}
+	}
+
+	return &scoped, key, nil
+}
+
+func (s *apiKeyService) findServiceAccount(orgID, userID uint64) (*models.User, error) {
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil || !user.IsServiceAccount {
+		return nil, errors.New("service account not found")
+	}
+	return user, nil
+}
+
+func (s *apiKeyService) validateScopes(scopes []models.Permission) error {
+	if len(scopes) == 0 {
+		return errors.New("at least one scope is required")
+	}
+
+	known, err := s.roleRepo.ListPermissions()
+	if err != nil {
+		return err
+	}
+
+	valid := make(map[models.Permission]bool, len(known))
+	for _, p := range known {
+		valid[p.Name] = true
+	}
+
+	for _, p := range scopes {
+		if !valid[p] {
+			return fmt.Errorf("unknown permission: %s", p)
+		}
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"errors"
+	"log"
+	"sync"
+	"time"
 
 	"compass-backend/config"
 	"compass-backend/internal/models"
@@ -9,55 +12,414 @@ import (
 	"compass-backend/internal/utils"
 )
 
+// ErrInvalidCredentials is returned for every rejected sign-in so that the
+// response does not reveal whether an account exists, is locked or inactive.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ThrottleError is returned when a client has failed to sign in too often and
+// has to back off before trying again.
+type ThrottleError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	return "too many failed sign-in attempts, please try again later"
+}
+
+// ClientInfo describes where a request came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// SignInResult carries either a token pair or, when the account uses
+// two-factor authentication, a short-lived MFA challenge token that has to be
+// exchanged through VerifyMFA.
+type SignInResult struct {
+	AccessToken           string
+	RefreshToken          string
+	User                  *models.User
+	MFARequired           bool
+	MFAEnrollmentRequired bool
+	MFAToken              string
+	RecoveryCodes         []string
+
+	// PasswordChangeRequired is set instead of a token pair when the password
+	// has expired; PasswordChangeToken has to be exchanged through
+	// RenewPassword.
+	PasswordChangeRequired bool
+	PasswordChangeToken    string
+}
+
 type AuthService interface {
-	SignIn(email, password string) (accessToken, refreshToken string, user *models.User, err error)
-	RefreshToken(refreshToken string) (newAccessToken, newRefreshToken string, err error)
+	SignIn(email, password string, client ClientInfo) (*SignInResult, error)
+	SignInExternal(user *models.User, client ClientInfo) (*SignInResult, error)
+	BeginMFAEnrollment(mfaToken string) (*MFAEnrollment, error)
+	VerifyMFA(mfaToken, code string, client ClientInfo) (*SignInResult, error)
+	RenewPassword(passwordToken, newPassword string, client ClientInfo) (*SignInResult, error)
+	ListSignInAttempts(filter repositories.SignInAttemptFilter) ([]models.SignInAttempt, error)
+	RefreshToken(refreshToken string, client ClientInfo) (newAccessToken, newRefreshToken string, err error)
+	Logout(sessionID string) error
 }
 
 type authService struct {
-	userRepo repositories.UserRepository
-	cfg      *config.Config
+	userRepo         repositories.UserRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	attemptRepo      repositories.SignInAttemptRepository
+	mfaService       MFAService
+	sessionService   SessionService
+	passwordPolicy   PasswordPolicy
+	cfg              *config.Config
 }
 
-func NewAuthService(userRepo repositories.UserRepository, cfg *config.Config) AuthService {
+func NewAuthService(userRepo repositories.UserRepository, refreshTokenRepo repositories.RefreshTokenRepository, attemptRepo repositories.SignInAttemptRepository, mfaService MFAService, sessionService SessionService, passwordPolicy PasswordPolicy, cfg *config.Config) AuthService {
 	return &authService{
-		userRepo: userRepo,
-		cfg:      cfg,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		attemptRepo:      attemptRepo,
+		mfaService:       mfaService,
+		sessionService:   sessionService,
+		passwordPolicy:   passwordPolicy,
+		cfg:              cfg,
 	}
 }
 
-func (s *authService) SignIn(email, password string) (string, string, *models.User, error) {
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// spendPasswordCheck runs a password comparison that is bound to fail so
+// that unknown accounts take as long to reject as known ones.
+func (s *authService) spendPasswordCheck(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = utils.HashPassword("compass-dummy-password", s.cfg)
+	})
+	utils.CheckPassword(password, dummyHash)
+}
+
+func (s *authService) SignIn(email, password string, client ClientInfo) (*SignInResult, error) {
+	if err := s.checkIPThrottle(email, client); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.FindByEmail(email)
 	if err != nil {
-		return "", "", nil, errors.New("invalid credentials")
+		s.spendPasswordCheck(password)
+		s.recordAttempt(email, nil, client, models.OutcomeInvalidCredentials)
+		return nil, ErrInvalidCredentials
 	}
 
-	if user.PasswordHash == nil {
-		return "", "", nil, errors.New("password not set")
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		s.spendPasswordCheck(password)
+		s.recordAttempt(email, user, client, models.OutcomeLocked)
+		return nil, ErrInvalidCredentials
 	}
 
-	if !utils.CheckPassword(password, *user.PasswordHash) {
-		return "", "", nil, errors.New("invalid credentials")
+	if user.PasswordHash == nil || !utils.CheckPassword(password, *user.PasswordHash) {
+		if user.PasswordHash == nil {
+			s.spendPasswordCheck(password)
+		}
+		s.registerFailure(user)
+		s.recordAttempt(email, user, client, models.OutcomeInvalidCredentials)
+		return nil, ErrInvalidCredentials
 	}
 
 	if user.AccountStatus != models.StatusActive {
-		return "", "", nil, errors.New("account is not active")
+		s.recordAttempt(email, user, client, models.OutcomeInactive)
+		return nil, ErrInvalidCredentials
+	}
+
+	// The password is only known now, so this is when a hash made with an
+	// older algorithm or weaker parameters can be replaced
+	if utils.NeedsRehash(*user.PasswordHash, s.cfg) {
+		s.rehashPassword(user, password)
+	}
+
+	// An expired password has to be replaced before the sign-in can go on
+	if s.passwordPolicy.IsExpired(user) {
+		passwordToken, err := utils.GenerateToken(user, utils.PasswordChangeToken, "", s.cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.clearFailures(user)
+		s.recordAttempt(email, user, client, models.OutcomePasswordExpired)
+		return &SignInResult{
+			User:                   user,
+			PasswordChangeRequired: true,
+			PasswordChangeToken:    passwordToken,
+		}, nil
 	}
 
-	accessToken, refreshToken, err := utils.GenerateTokenPair(user, s.cfg)
+	return s.finishSignIn(email, user, client, models.OutcomeSuccess)
+}
+
+// RenewPassword sets a new password for a user whose password had expired at
+// sign-in and then continues the sign-in, including any MFA challenge.
+func (s *authService) RenewPassword(passwordToken, newPassword string, client ClientInfo) (*SignInResult, error) {
+	claims, err := utils.ValidateToken(passwordToken, utils.PasswordChangeToken, s.cfg)
 	if err != nil {
-		return "", "", nil, err
+		return nil, errors.New("invalid or expired password change token")
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil || user.AccountStatus != models.StatusActive || user.TokenVersion != claims.Version {
+		return nil, errors.New("invalid or expired password change token")
+	}
+
+	// The token is spent once the password was changed
+	if user.PasswordChangedAt != nil && claims.IssuedAt != nil && user.PasswordChangedAt.After(claims.IssuedAt.Time) {
+		return nil, errors.New("invalid or expired password change token")
+	}
+
+	hashedPassword, err := s.passwordPolicy.Hash(user, newPassword)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdatePassword(user.UserID, hashedPassword); err != nil {
+		return nil, err
+	}
+	if err := s.passwordPolicy.Remember(user.UserID, hashedPassword); err != nil {
+		return nil, err
+	}
+	user.PasswordHash = &hashedPassword
+
+	return s.finishSignIn(user.Email, user, client, models.OutcomeSuccess)
+}
+
+// SignInExternal completes a sign-in whose identity was already proven to an
+// external identity provider. Accounts with two-factor authentication still
+// have to pass the MFA challenge.
+func (s *authService) SignInExternal(user *models.User, client ClientInfo) (*SignInResult, error) {
+	if user.AccountStatus != models.StatusActive || user.IsServiceAccount {
+		s.recordAttempt(user.Email, user, client, models.OutcomeInactive)
+		return nil, ErrInvalidCredentials
+	}
+
+	// A lockout applies to every way of signing in, not just passwords
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		s.recordAttempt(user.Email, user, client, models.OutcomeLocked)
+		return nil, ErrInvalidCredentials
 	}
 
-	return accessToken, refreshToken, user, nil
+	return s.finishSignIn(user.Email, user, client, models.OutcomeSSOSuccess)
 }
 
-func (s *authService) RefreshToken(refreshToken string) (string, string, error) {
+// finishSignIn hands out an MFA challenge when the account needs one and
+// starts a session otherwise.
+func (s *authService) finishSignIn(email string, user *models.User, client ClientInfo, outcome models.SignInOutcome) (*SignInResult, error) {
+	required, err := s.mfaService.IsRequired(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first factor alone is not enough; hand out a challenge token for the
+	// second step instead of a token pair
+	if user.MFAEnabled || required {
+		mfaToken, err := utils.GenerateToken(user, utils.MFAToken, "", s.cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.recordAttempt(email, user, client, models.OutcomeMFAChallenge)
+		return &SignInResult{
+			User:                  user,
+			MFARequired:           true,
+			MFAEnrollmentRequired: !user.MFAEnabled,
+			MFAToken:              mfaToken,
+		}, nil
+	}
+
+	s.clearFailures(user)
+	s.recordAttempt(email, user, client, outcome)
+	return s.startSession(user, client)
+}
+
+// BeginMFAEnrollment lets a user whom policy requires to use MFA enroll
+// during sign-in, before they hold an access token.
+func (s *authService) BeginMFAEnrollment(mfaToken string) (*MFAEnrollment, error) {
+	user, err := s.userFromMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mfaService.BeginEnrollment(user.UserID)
+}
+
+func (s *authService) VerifyMFA(mfaToken, code string, client ClientInfo) (*SignInResult, error) {
+	user, err := s.userFromMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkIPThrottle(user.Email, client); err != nil {
+		return nil, err
+	}
+
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		s.recordAttempt(user.Email, user, client, models.OutcomeLocked)
+		return nil, errors.New("invalid verification code")
+	}
+
+	// Users completing a policy-enforced enrollment confirm their first code
+	// here and receive their recovery codes along with the tokens
+	if !user.MFAEnabled {
+		recoveryCodes, err := s.mfaService.ConfirmEnrollment(user.UserID, code)
+		if err != nil {
+			s.registerFailure(user)
+			s.recordAttempt(user.Email, user, client, models.OutcomeMFAFailed)
+			return nil, err
+		}
+		s.clearFailures(user)
+		s.recordAttempt(user.Email, user, client, models.OutcomeSuccess)
+		result, err := s.startSession(user, client)
+		if err != nil {
+			return nil, err
+		}
+		result.RecoveryCodes = recoveryCodes
+		return result, nil
+	}
+
+	ok, err := s.mfaService.VerifyCode(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.registerFailure(user)
+		s.recordAttempt(user.Email, user, client, models.OutcomeMFAFailed)
+		return nil, errors.New("invalid verification code")
+	}
+
+	s.clearFailures(user)
+	s.recordAttempt(user.Email, user, client, models.OutcomeSuccess)
+	return s.startSession(user, client)
+}
+
+func (s *authService) ListSignInAttempts(filter repositories.SignInAttemptFilter) ([]models.SignInAttempt, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.attemptRepo.List(filter)
+}
+
+// rehashPassword stores a new hash of the password with the current settings.
+// Failures are only logged since the old hash still works.
+func (s *authService) rehashPassword(user *models.User, password string) {
+	hashedPassword, err := utils.HashPassword(password, s.cfg)
+	if err != nil {
+		log.Printf("Failed to rehash password for user %d: %v", user.UserID, err)
+		return
+	}
+
+	if err := s.userRepo.ReplacePasswordHash(user.UserID, *user.PasswordHash, hashedPassword); err != nil {
+		log.Printf("Failed to rehash password for user %d: %v", user.UserID, err)
+		return
+	}
+	user.PasswordHash = &hashedPassword
+}
+
+// checkIPThrottle applies exponential backoff to clients that keep failing
+// from the same IP, whichever accounts they are trying.
+func (s *authService) checkIPThrottle(email string, client ClientInfo) error {
+	failures, last, err := s.attemptRepo.FailureStatsByIP(client.IP, time.Now().Add(-s.cfg.Lockout.IPWindow))
+	if err != nil {
+		return err
+	}
+
+	excess := int(failures) - s.cfg.Lockout.IPMaxFailures
+	if excess < 0 {
+		return nil
+	}
+
+	retryAt := last.Add(s.backoff(excess))
+	if wait := time.Until(retryAt); wait > 0 {
+		s.recordAttempt(email, nil, client, models.OutcomeThrottled)
+		return &ThrottleError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// registerFailure counts a failed attempt against the account and locks it
+// once the threshold is reached. Each further failure doubles the lockout.
+func (s *authService) registerFailure(user *models.User) {
+	attempts, err := s.userRepo.IncrementLoginFailures(user.UserID)
+	if err != nil {
+		log.Printf("Failed to record sign-in failure for user %d: %v", user.UserID, err)
+		return
+	}
+
+	if excess := attempts - s.cfg.Lockout.MaxFailedAttempts; excess >= 0 {
+		if err := s.userRepo.LockUntil(user.UserID, time.Now().Add(s.backoff(excess))); err != nil {
+			log.Printf("Failed to lock user %d: %v", user.UserID, err)
+		}
+	}
+}
+
+func (s *authService) clearFailures(user *models.User) {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return
+	}
+	if err := s.userRepo.ResetLoginFailures(user.UserID); err != nil {
+		log.Printf("Failed to reset sign-in failures for user %d: %v", user.UserID, err)
+	}
+}
+
+func (s *authService) backoff(excess int) time.Duration {
+	wait := s.cfg.Lockout.BaseDuration
+	for i := 0; i < excess && wait < s.cfg.Lockout.MaxDuration; i++ {
+		wait *= 2
+	}
+	if wait > s.cfg.Lockout.MaxDuration {
+		wait = s.cfg.Lockout.MaxDuration
+	}
+	return wait
+}
+
+func (s *authService) recordAttempt(email string, user *models.User, client ClientInfo, outcome models.SignInOutcome) {
+	attempt := &models.SignInAttempt{
+		Email:     email,
+		IPAddress: client.IP,
+		UserAgent: client.UserAgent,
+		Outcome:   outcome,
+	}
+	if user != nil {
+		attempt.UserID = &user.UserID
+		attempt.OrganizationID = user.OrganizationID
+	}
+
+	if err := s.attemptRepo.Create(attempt); err != nil {
+		log.Printf("Failed to record sign-in attempt for %s: %v", email, err)
+	}
+}
+
+func (s *authService) RefreshToken(refreshToken string, client ClientInfo) (string, string, error) {
 	claims, err := utils.ValidateToken(refreshToken, utils.RefreshToken, s.cfg)
 	if err != nil {
 		return "", "", errors.New("invalid refresh token")
 	}
 
+	stored, err := s.refreshTokenRepo.FindByHash(utils.HashToken(refreshToken))
+	if err != nil || stored.UserID != claims.UserID {
+		return "", "", errors.New("invalid refresh token")
+	}
+
+	if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return "", "", errors.New("invalid refresh token")
+	}
+
+	// A refresh token can only be exchanged once. Seeing a rotated token again
+	// means it was copied, so the whole family is revoked and both the
+	// legitimate client and the attacker have to sign in again.
+	rotated, err := s.refreshTokenRepo.MarkRotated(stored.TokenID)
+	if err != nil {
+		return "", "", err
+	}
+	if !rotated {
+		if err := s.refreshTokenRepo.RevokeFamily(stored.FamilyID); err != nil {
+			return "", "", err
+		}
+		return "", "", errors.New("refresh token reuse detected")
+	}
+
 	user, err := s.userRepo.FindByID(claims.UserID)
 	if err != nil {
 		return "", "", errors.New("user not found")
@@ -67,10 +429,80 @@ func (s *authService) RefreshToken(refreshToken string) (string, string, error)
 		return "", "", errors.New("account is not active")
 	}
 
-	newAccessToken, newRefreshToken, err := utils.GenerateTokenPair(user, s.cfg)
+	if user.TokenVersion != claims.Version {
+		return "", "", errors.New("invalid refresh token")
+	}
+
+	newAccessToken, newRefreshToken, err := s.issueTokenPair(user, stored.FamilyID)
 	if err != nil {
 		return "", "", err
 	}
 
+	if err := s.sessionService.RefreshSession(stored.FamilyID, client); err != nil {
+		return "", "", err
+	}
+
 	return newAccessToken, newRefreshToken, nil
-}
\ No newline at end of file
+}
+
+func (s *authService) Logout(sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return s.sessionService.EndSession(sessionID)
+}
+
+func (s *authService) userFromMFAToken(mfaToken string) (*models.User, error) {
+	claims, err := utils.ValidateToken(mfaToken, utils.MFAToken, s.cfg)
+	if err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil || user.AccountStatus != models.StatusActive || user.TokenVersion != claims.Version {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+
+	return user, nil
+}
+
+// startSession records a new session and issues the first token pair of its
+// refresh token family.
+func (s *authService) startSession(user *models.User, client ClientInfo) (*SignInResult, error) {
+	session, err := s.sessionService.StartSession(user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, refreshToken, err := s.issueTokenPair(user, session.SessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignInResult{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User:         user,
+	}, nil
+}
+
+// issueTokenPair generates a token pair within a refresh token family and
+// persists the hash of the refresh token.
+func (s *authService) issueTokenPair(user *models.User, familyID string) (string, string, error) {
+	accessToken, refreshToken, err := utils.GenerateTokenPair(user, familyID, s.cfg)
+	if err != nil {
+		return "", "", err
+	}
+
+	record := &models.RefreshToken{
+		UserID:    user.UserID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.cfg.JWT.RefreshTokenDuration),
+	}
+	if err := s.refreshTokenRepo.Create(record); err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
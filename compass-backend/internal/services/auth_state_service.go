@@ -0,0 +1,124 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+)
+
+// authStateCacheTTL bounds how long another instance may keep accepting a
+// token after it was revoked. Revocations made by this process evict the
+// cache entry immediately.
+const authStateCacheTTL = 15 * time.Second
+
+// AuthState is the current server-side view of a user that every access token
+// is checked against.
+type AuthState struct {
+	UserID         uint64
+	OrganizationID uint64
+	Email          string
+	Role           models.UserRole
+	AccountStatus  models.AccountStatus
+	TokenVersion   int
+	Permissions    models.PermissionSet
+}
+
+type AuthStateService interface {
+	GetState(userID uint64) (*AuthState, error)
+	RevokeTokens(userID uint64) error
+	Invalidate(userID uint64)
+	InvalidateAll()
+}
+
+type authStateEntry struct {
+	state     *AuthState
+	expiresAt time.Time
+}
+
+type authStateService struct {
+	userRepo         repositories.UserRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	sessionRepo      repositories.SessionRepository
+	roleRepo         repositories.RoleRepository
+
+	mu    sync.Mutex
+	cache map[uint64]authStateEntry
+}
+
+func NewAuthStateService(userRepo repositories.UserRepository, refreshTokenRepo repositories.RefreshTokenRepository, sessionRepo repositories.SessionRepository, roleRepo repositories.RoleRepository) AuthStateService {
+	return &authStateService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		roleRepo:         roleRepo,
+		cache:            make(map[uint64]authStateEntry),
+	}
+}
+
+func (s *authStateService) GetState(userID uint64) (*AuthState, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[userID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.state, nil
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := s.roleRepo.PermissionsForRole(user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &AuthState{
+		UserID:         user.UserID,
+		OrganizationID: user.OrgID(),
+		Email:          user.Email,
+		Role:           user.Role,
+		AccountStatus:  user.AccountStatus,
+		TokenVersion:   user.TokenVersion,
+		Permissions:    make(models.PermissionSet, len(permissions)),
+	}
+	for _, p := range permissions {
+		state.Permissions[p] = true
+	}
+
+	s.mu.Lock()
+	s.cache[userID] = authStateEntry{state: state, expiresAt: time.Now().Add(authStateCacheTTL)}
+	s.mu.Unlock()
+
+	return state, nil
+}
+
+// RevokeTokens invalidates every access and refresh token issued to the user
+// so far by bumping their token version, and ends their sessions.
+func (s *authStateService) RevokeTokens(userID uint64) error {
+	if err := s.userRepo.IncrementTokenVersion(userID); err != nil {
+		return err
+	}
+	s.Invalidate(userID)
+
+	if err := s.sessionRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeAllForUser(userID)
+}
+
+func (s *authStateService) Invalidate(userID uint64) {
+	s.mu.Lock()
+	delete(s.cache, userID)
+	s.mu.Unlock()
+}
+
+// InvalidateAll drops every cached state, e.g. after a role's permissions
+// were changed.
+func (s *authStateService) InvalidateAll() {
+	s.mu.Lock()
+	s.cache = make(map[uint64]authStateEntry)
+	s.mu.Unlock()
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"compass-backend/config"
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
+)
+
+var (
+	ErrImpersonationForbidden = errors.New("this user cannot be impersonated")
+	ErrImpersonationEnded     = errors.New("impersonation has ended")
+)
+
+// ImpersonationToken is the access token handed to an admin who impersonates
+// a user.
+type ImpersonationToken struct {
+	AccessToken   string
+	Impersonation *models.Impersonation
+	User          *models.User
+}
+
+type ImpersonationService interface {
+	Start(orgID, actorID, userID uint64, sessionID, reason string, client ClientInfo) (*ImpersonationToken, error)
+	Validate(claims *utils.Claims) error
+	Stop(impersonationID uint64) error
+	RecordRequest(impersonationID uint64, method, path string, statusCode int, ip string)
+	ListImpersonations(filter repositories.ImpersonationFilter) ([]models.Impersonation, error)
+	GetImpersonation(orgID, id uint64) (*models.Impersonation, error)
+}
+
+type impersonationService struct {
+	impersonationRepo repositories.ImpersonationRepository
+	userRepo          repositories.UserRepository
+	authStateService  AuthStateService
+	cfg               *config.Config
+}
+
+func NewImpersonationService(impersonationRepo repositories.ImpersonationRepository, userRepo repositories.UserRepository, authStateService AuthStateService, cfg *config.Config) ImpersonationService {
+	return &impersonationService{
+		impersonationRepo: impersonationRepo,
+		userRepo:          userRepo,
+		authStateService:  authStateService,
+		cfg:               cfg,
+	}
+}
+
+// Start issues a short-lived access token that acts as the user on behalf of
+// the admin. Admins, and users holding any permission the admin lacks, cannot
+// be impersonated so that impersonation never widens what the admin can do.
+func (s *impersonationService) Start(orgID, actorID, userID uint64, sessionID, reason string, client ClientInfo) (*ImpersonationToken, error) {
+	if sessionID == "" {
+		return nil, errors.New("impersonation requires a signed-in session")
+	}
+	if actorID == userID {
+		return nil, errors.New("you cannot impersonate yourself")
+	}
+
+	actor, err := s.userRepo.FindInOrganization(orgID, actorID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if user.IsServiceAccount || user.Role == models.RoleAdmin || user.Role == models.RoleSuperAdmin {
+		return nil, ErrImpersonationForbidden
+	}
+	if user.AccountStatus != models.StatusActive {
+		return nil, errors.New("only active users can be impersonated")
+	}
+
+	actorState, err := s.authStateService.GetState(actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	userState, err := s.authStateService.GetState(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if userState.Permissions.Has(models.PermUserImpersonate) {
+		return nil, ErrImpersonationForbidden
+	}
+	for p := range userState.Permissions {
+		if !actorState.Permissions.Has(p) {
+			return nil, ErrImpersonationForbidden
+		}
+	}
+
+	impersonation := &models.Impersonation{
+		OrganizationID: orgID,
+		ActorID:        actor.UserID,
+		UserID:         user.UserID,
+		Reason:         reason,
+		SessionID:      sessionID,
+		IPAddress:      client.IP,
+		ExpiresAt:      time.Now().Add(s.cfg.JWT.ImpersonationTokenDuration),
+	}
+	if err := s.impersonationRepo.Create(impersonation); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateImpersonationToken(user, actor, impersonation.ImpersonationID, sessionID, impersonation.ExpiresAt, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImpersonationToken{
+		AccessToken:   accessToken,
+		Impersonation: impersonation,
+		User:          user,
+	}, nil
+}
+
+// Validate checks that an impersonation token is still backed by an active
+// impersonation and an admin who may still impersonate.
+func (s *impersonationService) Validate(claims *utils.Claims) error {
+	impersonation, err := s.impersonationRepo.FindActive(claims.Actor.ImpersonationID)
+	if err != nil || impersonation.ActorID != claims.Actor.UserID || impersonation.UserID != claims.UserID {
+		return ErrImpersonationEnded
+	}
+
+	actorState, err := s.authStateService.GetState(claims.Actor.UserID)
+	if err != nil || actorState.TokenVersion != claims.Actor.Version || actorState.AccountStatus != models.StatusActive ||
+		actorState.OrganizationID != impersonation.OrganizationID || !actorState.Permissions.Has(models.PermUserImpersonate) {
+		return ErrImpersonationEnded
+	}
+	return nil
+}
+
+func (s *impersonationService) Stop(impersonationID uint64) error {
+	return s.impersonationRepo.End(impersonationID)
+}
+
+// RecordRequest adds a request made with an impersonation token to the log.
+// The request has already been served, so failures are only logged.
+func (s *impersonationService) RecordRequest(impersonationID uint64, method, path string, statusCode int, ip string) {
+	request := &models.ImpersonationRequest{
+		ImpersonationID: impersonationID,
+		Method:          method,
+		Path:            truncate(path, 500),
+		StatusCode:      statusCode,
+		IPAddress:       ip,
+	}
+	if err := s.impersonationRepo.CreateRequest(request); err != nil {
+		log.Printf("Failed to record impersonated request %s %s: %v", method, path, err)
+	}
+}
+
+func (s *impersonationService) ListImpersonations(filter repositories.ImpersonationFilter) ([]models.Impersonation, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.impersonationRepo.List(filter)
+}
+
+func (s *impersonationService) GetImpersonation(orgID, id uint64) (*models.Impersonation, error) {
+	impersonation, err := s.impersonationRepo.FindByID(orgID, id)
+	if err != nil {
+		return nil, errors.New("impersonation not found")
+	}
+	return impersonation, nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"compass-backend/config"
+	"compass-backend/internal/mailer"
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
+)
+
+type InviteService interface {
+	SendInvite(user *models.User, invitedBy uint64) error
+	ResendInvite(orgID, userID uint64, invitedBy uint64) error
+	RevokeInvite(orgID, userID uint64) error
+	AcceptInvite(token, password string) (*models.User, error)
+}
+
+type inviteService struct {
+	inviteRepo     repositories.InviteRepository
+	userRepo       repositories.UserRepository
+	passwordPolicy PasswordPolicy
+	mailer         mailer.Mailer
+	cfg            *config.Config
+}
+
+func NewInviteService(inviteRepo repositories.InviteRepository, userRepo repositories.UserRepository, passwordPolicy PasswordPolicy, mailer mailer.Mailer, cfg *config.Config) InviteService {
+	return &inviteService{
+		inviteRepo:     inviteRepo,
+		userRepo:       userRepo,
+		passwordPolicy: passwordPolicy,
+		mailer:         mailer,
+		cfg:            cfg,
+	}
+}
+
+// SendInvite issues a new activation link for a pending user, replacing any
+// link that is still outstanding, and mails it to them.
+func (s *inviteService) SendInvite(user *models.User, invitedBy uint64) error {
+	if user.AccountStatus != models.StatusPending {
+		return errors.New("user is not pending activation")
+	}
+
+	if _, err := s.inviteRepo.RevokeActiveForUser(user.UserID); err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateSignedToken(s.cfg.JWT.Secret)
+	if err != nil {
+		return err
+	}
+
+	invite := &models.UserInvite{
+		UserID:    user.UserID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.Invite.TokenDuration),
+		CreatedBy: invitedBy,
+	}
+	if err := s.inviteRepo.Create(invite); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.cfg.Invite.AcceptURL, url.QueryEscape(token))
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "You have been invited to Compass",
+		Body: fmt.Sprintf("Hello %s,\n\nAn account has been created for you on Compass. "+
+			"Follow the link below to choose a password and activate your account:\n\n%s\n\n"+
+			"This link can be used once and expires on %s.\n",
+			user.FullName, link, invite.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send invite email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *inviteService) ResendInvite(orgID, userID uint64, invitedBy uint64) error {
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.ErasedAt != nil {
+		return ErrUserErased
+	}
+
+	return s.SendInvite(user, invitedBy)
+}
+
+func (s *inviteService) RevokeInvite(orgID, userID uint64) error {
+	if _, err := s.userRepo.FindInOrganization(orgID, userID); err != nil {
+		return errors.New("user not found")
+	}
+
+	revoked, err := s.inviteRepo.RevokeActiveForUser(userID)
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return errors.New("no outstanding invite for this user")
+	}
+	return nil
+}
+
+func (s *inviteService) AcceptInvite(token, password string) (*models.User, error) {
+	if !utils.VerifySignedToken(token, s.cfg.JWT.Secret) {
+		return nil, errors.New("invalid or expired invite")
+	}
+
+	invite, err := s.inviteRepo.FindByHash(utils.HashToken(token))
+	if err != nil {
+		return nil, errors.New("invalid or expired invite")
+	}
+
+	user, err := s.userRepo.FindByID(invite.UserID)
+	if err != nil || user.AccountStatus != models.StatusPending {
+		return nil, errors.New("invalid or expired invite")
+	}
+
+	// Check the password first so a rejected one does not use up the invite
+	hashedPassword, err := s.passwordPolicy.Hash(user, password)
+	if err != nil {
+		return nil, err
+	}
+
+	// Consuming the invite and activating the account happen together, so a
+	// failure cannot use up the invite without activating the account
+	accepted, err := s.inviteRepo.Accept(invite, hashedPassword)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, errors.New("invalid or expired invite")
+	}
+
+	// The account is active by now, so a failure here must not be reported
+	// as a failed acceptance
+	if err := s.passwordPolicy.Remember(user.UserID, hashedPassword); err != nil {
+		log.Printf("Failed to record password history for user %d: %v", user.UserID, err)
+	}
+
+	user.PasswordHash = &hashedPassword
+	user.AccountStatus = models.StatusActive
+	return user, nil
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"compass-backend/config"
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
+)
+
+const recoveryCodeCount = 10
+
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type MFAService interface {
+	BeginEnrollment(userID uint64) (*MFAEnrollment, error)
+	ConfirmEnrollment(userID uint64, code string) ([]string, error)
+	Disable(userID uint64, code string) error
+	RegenerateRecoveryCodes(userID uint64, code string) ([]string, error)
+	VerifyCode(user *models.User, code string) (bool, error)
+	IsRequired(user *models.User) (bool, error)
+}
+
+type mfaService struct {
+	mfaRepo         repositories.MFARepository
+	userRepo        repositories.UserRepository
+	settingsService SettingsService
+	cfg             *config.Config
+}
+
+func NewMFAService(mfaRepo repositories.MFARepository, userRepo repositories.UserRepository, settingsService SettingsService, cfg *config.Config) MFAService {
+	return &mfaService{
+		mfaRepo:         mfaRepo,
+		userRepo:        userRepo,
+		settingsService: settingsService,
+		cfg:             cfg,
+	}
+}
+
+// BeginEnrollment generates a new TOTP secret for the user. It only takes
+// effect once a code generated from it is confirmed.
+func (s *mfaService) BeginEnrollment(userID uint64) (*MFAEnrollment, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.MFAEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.SetPendingSecret(userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(s.cfg.MFA.Issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *mfaService) ConfirmEnrollment(userID uint64, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.MFAEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	if user.TOTPSecret == nil {
+		return nil, errors.New("two-factor enrollment has not been started")
+	}
+
+	ok, err := s.useTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid verification code")
+	}
+
+	if err := s.mfaRepo.Enable(userID); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(userID)
+}
+
+func (s *mfaService) Disable(userID uint64, code string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if !user.MFAEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	required, err := s.IsRequired(user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("two-factor authentication is required for this account")
+	}
+
+	ok, err := s.VerifyCode(user, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("invalid verification code")
+	}
+
+	return s.mfaRepo.Disable(userID)
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(userID uint64, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.MFAEnabled {
+		return nil, errors.New("two-factor authentication is not enabled")
+	}
+
+	ok, err := s.useTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("invalid verification code")
+	}
+
+	return s.issueRecoveryCodes(userID)
+}
+
+// VerifyCode accepts either a current TOTP code or an unused recovery code,
+// consuming the latter.
+func (s *mfaService) VerifyCode(user *models.User, code string) (bool, error) {
+	if !user.MFAEnabled || user.TOTPSecret == nil {
+		return false, nil
+	}
+
+	ok, err := s.useTOTP(user, code)
+	if err != nil || ok {
+		return ok, err
+	}
+
+	return s.mfaRepo.UseRecoveryCode(user.UserID, utils.HashToken(normalizeRecoveryCode(code)))
+}
+
+// useTOTP accepts a TOTP code once. A code of the same or an earlier time step
+// than the last accepted one is refused, even within its validity window.
+func (s *mfaService) useTOTP(user *models.User, code string) (bool, error) {
+	if user.TOTPSecret == nil {
+		return false, nil
+	}
+
+	step, ok := utils.ValidateTOTP(*user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return s.mfaRepo.UseTOTPStep(user.UserID, step)
+}
+
+// IsRequired reports whether policy forces the user to use MFA.
+func (s *mfaService) IsRequired(user *models.User) (bool, error) {
+	if user.Role != models.RoleAdmin {
+		return false, nil
+	}
+
+	policy, err := s.settingsService.GetSecurityPolicy(user.OrgID())
+	if err != nil {
+		return false, err
+	}
+	return policy.RequireAdminMFA, nil
+}
+
+func (s *mfaService) issueRecoveryCodes(userID uint64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := utils.GenerateRandomToken(5)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, fmt.Sprintf("%s-%s", raw[:5], raw[5:]))
+		hashes = append(hashes, utils.HashToken(raw))
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.ReplaceAll(code, "-", "")
+}
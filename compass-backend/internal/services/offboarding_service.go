@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+)
+
+type OffboardingService interface {
+	Offboard(orgID, actorID, userID, successorID uint64, dryRun bool) (*repositories.OffboardingPlan, error)
+}
+
+type offboardingService struct {
+	offboardingRepo  repositories.OffboardingRepository
+	userRepo         repositories.UserRepository
+	authStateService AuthStateService
+}
+
+func NewOffboardingService(offboardingRepo repositories.OffboardingRepository, userRepo repositories.UserRepository, authStateService AuthStateService) OffboardingService {
+	return &offboardingService{
+		offboardingRepo:  offboardingRepo,
+		userRepo:         userRepo,
+		authStateService: authStateService,
+	}
+}
+
+// Offboard disables a departing user and hands the projects they created or
+// own and the users they invited to a successor. With dryRun nothing changes
+// and the returned plan only describes what would move.
+func (s *offboardingService) Offboard(orgID, actorID, userID, successorID uint64, dryRun bool) (*repositories.OffboardingPlan, error) {
+	if userID == actorID {
+		return nil, errors.New("you cannot offboard yourself")
+	}
+	if userID == successorID {
+		return nil, errors.New("a user cannot be their own successor")
+	}
+
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.IsServiceAccount {
+		return nil, errors.New("service accounts are removed by revoking their API keys")
+	}
+
+	successor, err := s.userRepo.FindInOrganization(orgID, successorID)
+	if err != nil {
+		return nil, errors.New("successor not found")
+	}
+	if successor.IsServiceAccount || successor.AccountStatus != models.StatusActive {
+		return nil, errors.New("successor must be an active user")
+	}
+
+	if err := ensureAdminRemains(s.userRepo, user); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return s.offboardingRepo.Plan(orgID, userID, successorID)
+	}
+
+	plan, err := s.offboardingRepo.Offboard(orgID, userID, successorID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.authStateService.Invalidate(userID)
+	return plan, nil
+}
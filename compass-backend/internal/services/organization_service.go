@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+)
+
+var orgSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,99}$`)
+
+type OrganizationService interface {
+	CreateOrganization(org *models.Organization) error
+	GetOrganization(orgID uint64) (*models.Organization, error)
+	ListOrganizations() ([]models.Organization, error)
+	CreateOrganizationAdmin(orgID uint64, admin *models.User, password string, createdBy uint64) error
+}
+
+type organizationService struct {
+	orgRepo     repositories.OrganizationRepository
+	userService UserService
+}
+
+func NewOrganizationService(orgRepo repositories.OrganizationRepository, userService UserService) OrganizationService {
+	return &organizationService{
+		orgRepo:     orgRepo,
+		userService: userService,
+	}
+}
+
+func (s *organizationService) CreateOrganization(org *models.Organization) error {
+	if !orgSlugPattern.MatchString(org.Slug) {
+		return errors.New("slug must be 2-100 lowercase letters, digits or '-' and start with a letter or digit")
+	}
+
+	if existing, _ := s.orgRepo.FindBySlug(org.Slug); existing != nil {
+		return errors.New("organization slug already exists")
+	}
+
+	return s.orgRepo.Create(org)
+}
+
+func (s *organizationService) GetOrganization(orgID uint64) (*models.Organization, error) {
+	return s.orgRepo.FindByID(orgID)
+}
+
+func (s *organizationService) ListOrganizations() ([]models.Organization, error) {
+	return s.orgRepo.List()
+}
+
+// CreateOrganizationAdmin adds an admin to an organization. Without a password
+// the admin is invited and activates their account through the emailed link.
+func (s *organizationService) CreateOrganizationAdmin(orgID uint64, admin *models.User, password string, createdBy uint64) error {
+	if _, err := s.orgRepo.FindByID(orgID); err != nil {
+		return errors.New("organization not found")
+	}
+
+	admin.OrganizationID = &orgID
+	admin.Role = models.RoleAdmin
+	return s.userService.CreateUser(admin, password, createdBy)
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"compass-backend/config"
+	"compass-backend/internal/breach"
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
+)
+
+// PasswordViolation describes one way a password fails the policy.
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError is returned when a new password is rejected. It lists
+// every rule the password breaks so that clients can show them all at once.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet the password policy"
+}
+
+type PasswordPolicy interface {
+	Hash(user *models.User, password string) (string, error)
+	Remember(userID uint64, hash string) error
+	IsExpired(user *models.User) bool
+}
+
+type passwordPolicy struct {
+	historyRepo repositories.PasswordHistoryRepository
+	breached    breach.Checker
+	cfg         *config.Config
+}
+
+func NewPasswordPolicy(historyRepo repositories.PasswordHistoryRepository, breached breach.Checker, cfg *config.Config) PasswordPolicy {
+	return &passwordPolicy{
+		historyRepo: historyRepo,
+		breached:    breached,
+		cfg:         cfg,
+	}
+}
+
+// Hash checks a new password for the user against the policy and returns its
+// hash. The user's current and recent passwords count as reuse.
+func (p *passwordPolicy) Hash(user *models.User, password string) (string, error) {
+	violations := p.checkRules(user, password)
+
+	breached, err := p.breached.IsBreached(password)
+	if err != nil {
+		// An unreadable list must not lock everyone out of changing passwords
+		log.Printf("Failed to check password against the breached password list: %v", err)
+	}
+	if breached {
+		violations = append(violations, PasswordViolation{
+			Code:    "breached",
+			Message: "password appears in a list of breached passwords",
+		})
+	}
+
+	if len(violations) == 0 && user.UserID != 0 {
+		reused, err := p.isReused(user, password)
+		if err != nil {
+			return "", err
+		}
+		if reused {
+			violations = append(violations, PasswordViolation{
+				Code:    "reused",
+				Message: fmt.Sprintf("password must differ from your last %d passwords", p.cfg.Password.HistorySize),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return "", &PasswordPolicyError{Violations: violations}
+	}
+
+	return utils.HashPassword(password, p.cfg)
+}
+
+// Remember records a password the user has just set and drops history beyond
+// the configured size.
+func (p *passwordPolicy) Remember(userID uint64, hash string) error {
+	if p.cfg.Password.HistorySize <= 0 {
+		return nil
+	}
+
+	if err := p.historyRepo.Create(&models.PasswordHistory{UserID: userID, PasswordHash: hash}); err != nil {
+		return err
+	}
+	return p.historyRepo.Prune(userID, p.cfg.Password.HistorySize)
+}
+
+func (p *passwordPolicy) IsExpired(user *models.User) bool {
+	if p.cfg.Password.MaxAge <= 0 || user.PasswordChangedAt == nil {
+		return false
+	}
+	return time.Since(*user.PasswordChangedAt) > p.cfg.Password.MaxAge
+}
+
+func (p *passwordPolicy) checkRules(user *models.User, password string) []PasswordViolation {
+	var violations []PasswordViolation
+
+	length := len([]rune(password))
+	if length < p.cfg.Password.MinLength {
+		violations = append(violations, PasswordViolation{
+			Code:    "too_short",
+			Message: fmt.Sprintf("password must be at least %d characters long", p.cfg.Password.MinLength),
+		})
+	}
+	if p.cfg.Password.MaxLength > 0 && length > p.cfg.Password.MaxLength {
+		violations = append(violations, PasswordViolation{
+			Code:    "too_long",
+			Message: fmt.Sprintf("password must be at most %d characters long", p.cfg.Password.MaxLength),
+		})
+	} else if p.cfg.PasswordHash.Algorithm == utils.HashBcrypt && len(password) > utils.BcryptMaxBytes {
+		// bcrypt cannot hash more than 72 bytes, and characters outside ASCII
+		// take several
+		violations = append(violations, PasswordViolation{
+			Code:    "too_long",
+			Message: fmt.Sprintf("password must be at most %d bytes long", utils.BcryptMaxBytes),
+		})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.cfg.Password.RequireUppercase && !hasUpper {
+		violations = append(violations, PasswordViolation{Code: "missing_uppercase", Message: "password must contain an uppercase letter"})
+	}
+	if p.cfg.Password.RequireLowercase && !hasLower {
+		violations = append(violations, PasswordViolation{Code: "missing_lowercase", Message: "password must contain a lowercase letter"})
+	}
+	if p.cfg.Password.RequireDigit && !hasDigit {
+		violations = append(violations, PasswordViolation{Code: "missing_digit", Message: "password must contain a digit"})
+	}
+	if p.cfg.Password.RequireSymbol && !hasSymbol {
+		violations = append(violations, PasswordViolation{Code: "missing_symbol", Message: "password must contain a symbol"})
+	}
+
+	if p.cfg.Password.DisallowPersonalInfo && containsPersonalInfo(user, password) {
+		violations = append(violations, PasswordViolation{
+			Code:    "personal_info",
+			Message: "password must not contain your email address or name",
+		})
+	}
+
+	return violations
+}
+
+func (p *passwordPolicy) isReused(user *models.User, password string) (bool, error) {
+	if p.cfg.Password.HistorySize <= 0 {
+		return false, nil
+	}
+
+	if user.PasswordHash != nil && utils.CheckPassword(password, *user.PasswordHash) {
+		return true, nil
+	}
+
+	history, err := p.historyRepo.ListRecent(user.UserID, p.cfg.Password.HistorySize)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range history {
+		if utils.CheckPassword(password, entry.PasswordHash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// containsPersonalInfo reports whether the password contains the local part
+// of the user's email address or any part of their name of three or more
+// characters, ignoring case.
+func containsPersonalInfo(user *models.User, password string) bool {
+	lowered := strings.ToLower(password)
+
+	local, _, _ := strings.Cut(strings.ToLower(user.Email), "@")
+	parts := strings.FieldsFunc(local, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	parts = append(parts, local)
+	parts = append(parts, strings.Fields(strings.ToLower(user.FullName))...)
+
+	for _, part := range parts {
+		if len([]rune(part)) >= 3 && strings.Contains(lowered, part) {
+			return true
+		}
+	}
+	return false
+}
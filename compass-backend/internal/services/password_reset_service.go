@@ -0,0 +1,139 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"compass-backend/config"
+	"compass-backend/internal/mailer"
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
+)
+
+var ErrTooManyResetRequests = errors.New("too many password reset requests, please try again later")
+
+type PasswordResetService interface {
+	RequestReset(email string) error
+	ResetPassword(token, newPassword string) error
+}
+
+type passwordResetService struct {
+	resetRepo        repositories.PasswordResetRepository
+	userRepo         repositories.UserRepository
+	authStateService AuthStateService
+	passwordPolicy   PasswordPolicy
+	mailer           mailer.Mailer
+	limiter          *utils.RateLimiter
+	cfg              *config.Config
+}
+
+func NewPasswordResetService(resetRepo repositories.PasswordResetRepository, userRepo repositories.UserRepository, authStateService AuthStateService, passwordPolicy PasswordPolicy, mailer mailer.Mailer, cfg *config.Config) PasswordResetService {
+	return &passwordResetService{
+		resetRepo:        resetRepo,
+		userRepo:         userRepo,
+		authStateService: authStateService,
+		passwordPolicy:   passwordPolicy,
+		mailer:           mailer,
+		limiter:          utils.NewRateLimiter(cfg.Reset.MaxRequests, cfg.Reset.RequestWindow),
+		cfg:              cfg,
+	}
+}
+
+// RequestReset mails a reset link to the address if it belongs to an active
+// account. The outcome is deliberately the same whether or not the address is
+// known, so callers must not surface anything but rate limiting to clients.
+func (s *passwordResetService) RequestReset(email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !s.limiter.Allow(email) {
+		return ErrTooManyResetRequests
+	}
+
+	user, err := s.userRepo.FindByEmail(email)
+	// Service accounts have no password to reset
+	if err != nil || user.AccountStatus != models.StatusActive || user.IsServiceAccount {
+		return nil
+	}
+
+	// Create and send the link in the background so response times do not
+	// reveal whether the address exists
+	go func() {
+		if err := s.sendReset(user); err != nil {
+			log.Printf("Failed to send password reset email to %s: %v", user.Email, err)
+		}
+	}()
+
+	return nil
+}
+
+// sendReset stores a new reset link for the user and mails it.
+func (s *passwordResetService) sendReset(user *models.User) error {
+	token, err := utils.GenerateSignedToken(s.cfg.JWT.Secret)
+	if err != nil {
+		return err
+	}
+
+	reset := &models.PasswordResetToken{
+		UserID:    user.UserID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.Reset.TokenDuration),
+	}
+	if err := s.resetRepo.Create(reset); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.cfg.Reset.ResetURL, url.QueryEscape(token))
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your Compass password",
+		Body: fmt.Sprintf("Hello %s,\n\nWe received a request to reset the password for your Compass account. "+
+			"Follow the link below to choose a new password:\n\n%s\n\n"+
+			"This link can be used once and expires in %s. If you did not request a reset you can ignore this email.\n",
+			user.FullName, link, s.cfg.Reset.TokenDuration),
+	})
+}
+
+func (s *passwordResetService) ResetPassword(token, newPassword string) error {
+	if !utils.VerifySignedToken(token, s.cfg.JWT.Secret) {
+		return errors.New("invalid or expired reset token")
+	}
+
+	reset, err := s.resetRepo.FindByHash(utils.HashToken(token))
+	if err != nil {
+		return errors.New("invalid or expired reset token")
+	}
+
+	user, err := s.userRepo.FindByID(reset.UserID)
+	if err != nil || user.AccountStatus != models.StatusActive {
+		return errors.New("invalid or expired reset token")
+	}
+
+	// Check the password first so a rejected one does not use up the link
+	hashedPassword, err := s.passwordPolicy.Hash(user, newPassword)
+	if err != nil {
+		return err
+	}
+
+	// Consuming the link and setting the password happen together, so a
+	// failure cannot use up the link without changing the password
+	redeemed, err := s.resetRepo.Redeem(reset, hashedPassword)
+	if err != nil {
+		return err
+	}
+	if !redeemed {
+		return errors.New("invalid or expired reset token")
+	}
+
+	// The password is changed by now, so a failure here must not be reported
+	// as a failed reset
+	if err := s.passwordPolicy.Remember(user.UserID, hashedPassword); err != nil {
+		log.Printf("Failed to record password history for user %d: %v", user.UserID, err)
+	}
+
+	// Sign the user out everywhere
+	return s.authStateService.RevokeTokens(user.UserID)
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"compass-backend/internal/repositories"
+)
+
+// avatarExtensions names the avatar file of an archive after its format.
+var avatarExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type PersonalDataService interface {
+	Export(orgID, userID uint64) (*repositories.PersonalData, error)
+	Erase(orgID, actorID, userID uint64, confirmEmail string) error
+}
+
+type personalDataService struct {
+	personalDataRepo repositories.PersonalDataRepository
+	userRepo         repositories.UserRepository
+	authStateService AuthStateService
+}
+
+func NewPersonalDataService(personalDataRepo repositories.PersonalDataRepository, userRepo repositories.UserRepository, authStateService AuthStateService) PersonalDataService {
+	return &personalDataService{
+		personalDataRepo: personalDataRepo,
+		userRepo:         userRepo,
+		authStateService: authStateService,
+	}
+}
+
+func (s *personalDataService) Export(orgID, userID uint64) (*repositories.PersonalData, error) {
+	data, err := s.personalDataRepo.Collect(orgID, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return data, nil
+}
+
+// Erase pseudonymises the user. confirmEmail must repeat the user's current
+// email address so that the wrong account is not erased by mistake.
+func (s *personalDataService) Erase(orgID, actorID, userID uint64, confirmEmail string) error {
+	if userID == actorID {
+		return errors.New("you cannot erase your own account")
+	}
+
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.ErasedAt != nil {
+		return errors.New("user has already been erased")
+	}
+	if user.IsServiceAccount {
+		return errors.New("service accounts hold no personal data")
+	}
+	if !strings.EqualFold(strings.TrimSpace(confirmEmail), user.Email) {
+		return errors.New("confirmation email does not match the user's email")
+	}
+
+	err = s.personalDataRepo.Erase(userID, repositories.Pseudonym{
+		FullName: "Erased user",
+		Email:    fmt.Sprintf("erased-%d@erased.invalid", userID),
+	})
+	if err != nil {
+		return err
+	}
+
+	s.authStateService.Invalidate(userID)
+	return nil
+}
+
+// WritePersonalDataArchive writes the export as a ZIP archive holding
+// personal_data.json and, if the user uploaded one, their avatar image.
+func WritePersonalDataArchive(w io.Writer, data *repositories.PersonalData) error {
+	archive := zip.NewWriter(w)
+
+	document := *data
+	document.Avatar = nil
+	file, err := archive.Create("personal_data.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+
+	if data.Avatar != nil {
+		file, err := archive.Create("avatar" + avatarExtensions[data.Avatar.ContentType])
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(data.Avatar.Data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	// Timezones are validated against the embedded database so that they do
+	// not depend on the zoneinfo files of the host
+	_ "time/tzdata"
+
+	"compass-backend/config"
+	"compass-backend/internal/mailer"
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
+)
+
+var ErrAvatarNotFound = errors.New("avatar not found")
+
+// avatarContentTypes are the image formats accepted as avatars.
+var avatarContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// ProfileUpdate holds the profile fields a user wants to change. Nil fields
+// are left as they are.
+type ProfileUpdate struct {
+	FullName       *string
+	Timezone       *string
+	RFIUpdates     *bool
+	ProjectUpdates *bool
+	WeeklyDigest   *bool
+}
+
+type ProfileService interface {
+	GetProfile(userID uint64) (*models.User, error)
+	UpdateProfile(userID uint64, update ProfileUpdate) (*models.User, error)
+	RequestEmailChange(userID uint64, newEmail, currentPassword string) error
+	ConfirmEmailChange(token string) error
+	SetAvatar(userID uint64, image io.Reader) error
+	RemoveAvatar(userID uint64) error
+	GetAvatar(orgID, userID uint64) (*models.UserAvatar, error)
+}
+
+type profileService struct {
+	userRepo         repositories.UserRepository
+	profileRepo      repositories.UserProfileRepository
+	authStateService AuthStateService
+	mailer           mailer.Mailer
+	cfg              *config.Config
+}
+
+func NewProfileService(userRepo repositories.UserRepository, profileRepo repositories.UserProfileRepository, authStateService AuthStateService, mailer mailer.Mailer, cfg *config.Config) ProfileService {
+	return &profileService{
+		userRepo:         userRepo,
+		profileRepo:      profileRepo,
+		authStateService: authStateService,
+		mailer:           mailer,
+		cfg:              cfg,
+	}
+}
+
+func (s *profileService) GetProfile(userID uint64) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func (s *profileService) UpdateProfile(userID uint64, update ProfileUpdate) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if update.FullName != nil {
+		fullName := strings.TrimSpace(*update.FullName)
+		if fullName == "" {
+			return nil, errors.New("full name cannot be empty")
+		}
+		user.FullName = fullName
+	}
+	if update.Timezone != nil {
+		if err := validateTimezone(*update.Timezone); err != nil {
+			return nil, err
+		}
+		user.Timezone = *update.Timezone
+	}
+	if update.RFIUpdates != nil {
+		user.NotificationPrefs.RFIUpdates = *update.RFIUpdates
+	}
+	if update.ProjectUpdates != nil {
+		user.NotificationPrefs.ProjectUpdates = *update.ProjectUpdates
+	}
+	if update.WeeklyDigest != nil {
+		user.NotificationPrefs.WeeklyDigest = *update.WeeklyDigest
+	}
+
+	if err := s.userRepo.UpdateProfile(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// RequestEmailChange mails a confirmation link to the new address. The address
+// only changes once the link is followed, and the current address is told
+// about the request so that a hijacked session cannot quietly take over the
+// account.
+func (s *profileService) RequestEmailChange(userID uint64, newEmail, currentPassword string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.IsServiceAccount {
+		return errors.New("service accounts have no email address to change")
+	}
+
+	// Accounts that only sign in through SSO have no password to confirm
+	if user.PasswordHash != nil && !utils.CheckPassword(currentPassword, *user.PasswordHash) {
+		return errors.New("current password is incorrect")
+	}
+
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if newEmail == strings.ToLower(user.Email) {
+		return errors.New("new email is the same as the current email")
+	}
+	if existing, _ := s.userRepo.FindByEmail(newEmail); existing != nil {
+		return errors.New("email already exists")
+	}
+
+	// Only the latest request can be confirmed
+	if err := s.profileRepo.InvalidateEmailChanges(user.UserID); err != nil {
+		return err
+	}
+
+	token, err := utils.GenerateSignedToken(s.cfg.JWT.Secret)
+	if err != nil {
+		return err
+	}
+
+	request := &models.EmailChangeRequest{
+		UserID:    user.UserID,
+		NewEmail:  newEmail,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.Profile.EmailChangeTokenDuration),
+	}
+	if err := s.profileRepo.CreateEmailChange(request); err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s?token=%s", s.cfg.Profile.EmailChangeURL, url.QueryEscape(token))
+	err = s.mailer.Send(mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new Compass email address",
+		Body: fmt.Sprintf("Hello %s,\n\nFollow the link below to use this address for your Compass account:\n\n%s\n\n"+
+			"This link can be used once and expires on %s. If you did not ask for this you can ignore this email.\n",
+			user.FullName, link, request.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send confirmation email: %w", err)
+	}
+
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your Compass email address is being changed",
+		Body: fmt.Sprintf("Hello %s,\n\nA change of the email address of your Compass account to %s was requested. "+
+			"It takes effect once confirmed from the new address. If this was not you, change your password "+
+			"and contact your administrator.\n",
+			user.FullName, newEmail),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send notification email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *profileService) ConfirmEmailChange(token string) error {
+	if !utils.VerifySignedToken(token, s.cfg.JWT.Secret) {
+		return errors.New("invalid or expired email change token")
+	}
+
+	request, err := s.profileRepo.FindEmailChangeByHash(utils.HashToken(token))
+	if err != nil {
+		return errors.New("invalid or expired email change token")
+	}
+
+	user, err := s.userRepo.FindByID(request.UserID)
+	if err != nil || user.AccountStatus == models.StatusDisabled {
+		return errors.New("invalid or expired email change token")
+	}
+
+	// The address may have been taken since the change was requested
+	if existing, _ := s.userRepo.FindByEmail(request.NewEmail); existing != nil {
+		return errors.New("email already exists")
+	}
+
+	// Consuming the link and changing the address happen together, so a
+	// failure cannot use up the link without changing the address
+	confirmed, err := s.profileRepo.ConfirmEmailChange(request)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return errors.New("invalid or expired email change token")
+	}
+
+	s.authStateService.Invalidate(user.UserID)
+	return nil
+}
+
+// SetAvatar stores an uploaded image as the user's avatar. The format is
+// detected from the content rather than trusted from the upload.
+func (s *profileService) SetAvatar(userID uint64, image io.Reader) error {
+	data, err := io.ReadAll(io.LimitReader(image, int64(s.cfg.Profile.AvatarMaxBytes)+1))
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return errors.New("avatar image is empty")
+	}
+	if len(data) > s.cfg.Profile.AvatarMaxBytes {
+		return fmt.Errorf("avatar image must be at most %d bytes", s.cfg.Profile.AvatarMaxBytes)
+	}
+
+	contentType := http.DetectContentType(data)
+	if !avatarContentTypes[contentType] {
+		return errors.New("avatar must be a PNG, JPEG, GIF or WebP image")
+	}
+
+	return s.profileRepo.SaveAvatar(&models.UserAvatar{
+		UserID:      userID,
+		ContentType: contentType,
+		Data:        data,
+	})
+}
+
+func (s *profileService) RemoveAvatar(userID uint64) error {
+	return s.profileRepo.DeleteAvatar(userID)
+}
+
+func (s *profileService) GetAvatar(orgID, userID uint64) (*models.UserAvatar, error) {
+	if _, err := s.userRepo.FindInOrganization(orgID, userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	avatar, err := s.profileRepo.FindAvatar(userID)
+	if err != nil {
+		return nil, ErrAvatarNotFound
+	}
+	return avatar, nil
+}
+
+// validateTimezone accepts IANA timezone names such as "Europe/Berlin".
+func validateTimezone(name string) error {
+	if name == "" || name == "Local" {
+		return errors.New("invalid timezone")
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return errors.New("invalid timezone")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"compass-backend/config"
+	"compass-backend/internal/repositories"
+)
+
+type ProjectPurgeService interface {
+	PurgeDeletedProjects() (int64, error)
+}
+
+type projectPurgeService struct {
+	projectRepo repositories.ProjectRepository
+	cfg         *config.Config
+}
+
+func NewProjectPurgeService(projectRepo repositories.ProjectRepository, cfg *config.Config) ProjectPurgeService {
+	return &projectPurgeService{
+		projectRepo: projectRepo,
+		cfg:         cfg,
+	}
+}
+
+// PurgeDeletedProjects permanently deletes the projects of every organization
+// that were deleted longer ago than the retention period.
+func (s *projectPurgeService) PurgeDeletedProjects() (int64, error) {
+	if s.cfg.Project.DeletedRetention <= 0 {
+		return 0, nil
+	}
+	return s.projectRepo.PurgeDeleted(time.Now().Add(-s.cfg.Project.DeletedRetention))
+}
+
+// StartProjectPurge purges deleted projects past their retention period in
+// the background, once at startup and then every interval.
+func StartProjectPurge(purgeService ProjectPurgeService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			purged, err := purgeService.PurgeDeletedProjects()
+			if err != nil {
+				log.Printf("Failed to purge deleted projects: %v", err)
+			} else if purged > 0 {
+				log.Printf("Purged %d deleted projects", purged)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -2,40 +2,111 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
+	"compass-backend/config"
 	"compass-backend/internal/models"
 	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
 	"compass-backend/db"
 )
 
+const (
+	defaultProjectPageSize = 50
+	maxProjectPageSize     = 200
+)
+
+var errProjectArchived = errors.New("archived projects cannot be changed, unarchive the project first")
+
+// projectStatusOrder is the order a project normally goes through its
+// statuses in. Moving to an earlier status is a backwards move.
+var projectStatusOrder = map[models.ProjectStatus]int{
+	models.StatusNotYetStarted: 0,
+	models.StatusProgress:      1,
+	models.StatusCompleted:     2,
+}
+
+// ProjectUpdate holds the project details an editor wants to change. Nil
+// fields are left as they are.
+type ProjectUpdate struct {
+	ProjectName    *string
+	CompanyName    *string
+	CompanyAddress *string
+	ProjectType    *models.ProjectType
+}
+
+// ProjectClone describes the project to create from an existing one. Nil
+// fields are copied from the source project.
+type ProjectClone struct {
+	ProjectName    string
+	CompanyName    *string
+	CompanyAddress *string
+	ProjectType    *models.ProjectType
+	IncludeRFIs    bool
+}
+
+// ProjectPage is one page of a project listing.
+type ProjectPage struct {
+	Projects   []models.Project
+	Total      int64
+	NextCursor string
+}
+
 type ProjectService interface {
-	CreateProject(project *models.Project) error
-	CreateProjectWithDetails(project *models.Project, specifications []models.ProjectSpecification, rfis []models.ProjectRFI) error
-	GetProject(projectID uint64) (*models.Project, error)
-	ListProjects() ([]models.Project, error)
-	UpdateProjectStatus(projectID uint64, status models.ProjectStatus, updatedBy uint64) error
-	DeleteProject(projectID uint64, userRole models.UserRole) error
+	CreateProject(actor Actor, project *models.Project) error
+	CreateProjectWithDetails(actor Actor, project *models.Project, specifications []models.ProjectSpecification, rfis []models.ProjectRFI) error
+	GetProject(actor Actor, projectID uint64) (*models.Project, error)
+	CloneProject(actor Actor, projectID uint64, clone ProjectClone) (*models.Project, error)
+	ListProjects(actor Actor, filter repositories.ProjectFilter, cursor string) (*ProjectPage, error)
+	UpdateProject(actor Actor, projectID uint64, update ProjectUpdate, version int) (*models.Project, error)
+	UpdateProjectStatus(actor Actor, projectID uint64, status models.ProjectStatus, reason string) error
+	ListStatusHistory(actor Actor, projectID uint64) ([]models.ProjectStatusChange, error)
+	ArchiveProject(actor Actor, projectID uint64) error
+	UnarchiveProject(actor Actor, projectID uint64) error
+	DeleteProject(actor Actor, projectID uint64) error
+	ListDeletedProjects(actor Actor) ([]models.Project, error)
+	RestoreProject(actor Actor, projectID uint64) error
+	ListMembers(actor Actor, projectID uint64) ([]models.ProjectMember, error)
+	AddMember(actor Actor, projectID, userID uint64, role models.ProjectMemberRole) (*models.ProjectMember, error)
+	UpdateMemberRole(actor Actor, projectID, userID uint64, role models.ProjectMemberRole) error
+	RemoveMember(actor Actor, projectID, userID uint64) error
 }
 
 type projectService struct {
 	projectRepo       repositories.ProjectRepository
 	specificationRepo repositories.SpecificationRepository
 	rfiRepo          repositories.RFIRepository
+	memberRepo        repositories.ProjectMemberRepository
+	userRepo          repositories.UserRepository
+	access            ProjectAccess
+	cfg               *config.Config
 }
 
-func NewProjectService(projectRepo repositories.ProjectRepository, specRepo repositories.SpecificationRepository, rfiRepo repositories.RFIRepository) ProjectService {
+func NewProjectService(projectRepo repositories.ProjectRepository, specRepo repositories.SpecificationRepository, rfiRepo repositories.RFIRepository, memberRepo repositories.ProjectMemberRepository, userRepo repositories.UserRepository, access ProjectAccess, cfg *config.Config) ProjectService {
 	return &projectService{
 		projectRepo:       projectRepo,
 		specificationRepo: specRepo,
 		rfiRepo:          rfiRepo,
+		memberRepo:        memberRepo,
+		userRepo:          userRepo,
+		access:            access,
+		cfg:               cfg,
 	}
 }
 
-func (s *projectService) CreateProject(project *models.Project) error {
-	return s.projectRepo.Create(project)
+func (s *projectService) CreateProject(actor Actor, project *models.Project) error {
+	return s.CreateProjectWithDetails(actor, project, nil, nil)
 }
 
-func (s *projectService) CreateProjectWithDetails(project *models.Project, specifications []models.ProjectSpecification, rfis []models.ProjectRFI) error {
+func (s *projectService) CreateProjectWithDetails(actor Actor, project *models.Project, specifications []models.ProjectSpecification, rfis []models.ProjectRFI) error {
+	if actor.OrganizationID == 0 {
+		return errors.New("projects must belong to an organization")
+	}
+	project.OrganizationID = actor.OrganizationID
+	project.CreatedBy = actor.UserID
+
 	// Start a database transaction
 	tx := db.GetDB().Begin()
 	if tx.Error != nil {
@@ -48,6 +119,18 @@ func (s *projectService) CreateProjectWithDetails(project *models.Project, speci
 		return err
 	}
 
+	// The creator owns the project
+	owner := &models.ProjectMember{
+		ProjectID:  project.ProjectID,
+		UserID:     project.CreatedBy,
+		MemberRole: models.MemberOwner,
+		AddedBy:    &project.CreatedBy,
+	}
+	if err := tx.Create(owner).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// Create specifications if provided
 	for i := range specifications {
 		specifications[i].ProjectID = project.ProjectID
@@ -70,30 +153,426 @@ func (s *projectService) CreateProjectWithDetails(project *models.Project, speci
 	return tx.Commit().Error
 }
 
-func (s *projectService) GetProject(projectID uint64) (*models.Project, error) {
-	return s.projectRepo.FindByID(projectID)
+func (s *projectService) GetProject(actor Actor, projectID uint64) (*models.Project, error) {
+	if err := s.access.Authorize(actor, projectID, AccessView); err != nil {
+		return nil, err
+	}
+
+	project, err := s.projectRepo.FindByID(actor.OrganizationID, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	return project, nil
+}
+
+// ListProjects returns one page of the projects the actor can see that match
+// the filter. Members without access to every project only see the projects
+// they belong to.
+func (s *projectService) ListProjects(actor Actor, filter repositories.ProjectFilter, cursor string) (*ProjectPage, error) {
+	filter.OrganizationID = actor.OrganizationID
+	filter.MemberID = nil
+	if !actor.Permissions.Has(models.PermProjectAccessAll) {
+		filter.MemberID = &actor.UserID
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultProjectPageSize
+	}
+	if filter.Limit > maxProjectPageSize {
+		filter.Limit = maxProjectPageSize
+	}
+
+	if cursor != "" {
+		var after repositories.ProjectCursor
+		if err := utils.DecodeCursor(cursor, &after); err != nil {
+			return nil, err
+		}
+		// A cursor only makes sense in the order it was made for
+		if after.Sort != filter.Sort || after.Descending != filter.Descending {
+			return nil, utils.ErrInvalidCursor
+		}
+		filter.After = &after
+	}
+
+	total, err := s.projectRepo.Count(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra project to learn whether another page follows
+	pageSize := filter.Limit
+	filter.Limit++
+	projects, err := s.projectRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ProjectPage{Projects: projects, Total: total}
+	if len(projects) > pageSize {
+		page.Projects = projects[:pageSize]
+		next, err := utils.EncodeCursor(filter.CursorFor(&page.Projects[pageSize-1]))
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+// CloneProject creates a project from an existing one for repeat work. The
+// new project gets the source's company details, its latest specification as
+// version 1 and, if asked for, its RFI questions without their answers.
+func (s *projectService) CloneProject(actor Actor, projectID uint64, clone ProjectClone) (*models.Project, error) {
+	if err := s.access.Authorize(actor, projectID, AccessView); err != nil {
+		return nil, err
+	}
+
+	source, err := s.projectRepo.FindByID(actor.OrganizationID, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+
+	name := strings.TrimSpace(clone.ProjectName)
+	if name == "" {
+		return nil, errors.New("project name cannot be empty")
+	}
+	project := &models.Project{
+		ProjectName:    name,
+		CompanyName:    source.CompanyName,
+		CompanyAddress: source.CompanyAddress,
+		ProjectType:    source.ProjectType,
+	}
+	if clone.CompanyName != nil {
+		project.CompanyName = strings.TrimSpace(*clone.CompanyName)
+	}
+	if clone.CompanyAddress != nil {
+		project.CompanyAddress = strings.TrimSpace(*clone.CompanyAddress)
+	}
+	if clone.ProjectType != nil {
+		switch *clone.ProjectType {
+		case models.TypeWindows, models.TypeDoors:
+			project.ProjectType = *clone.ProjectType
+		default:
+			return nil, errors.New("invalid project type")
+		}
+	}
+
+	// FindByID loads only the latest specification
+	var specifications []models.ProjectSpecification
+	if len(source.Specifications) > 0 {
+		spec := source.Specifications[0]
+		spec.SpecificationID = 0
+		spec.ProjectID = 0
+		spec.Project = nil
+		spec.VersionNo = 1
+		spec.CreatedBy = actor.UserID
+		spec.Creator = nil
+		specifications = append(specifications, spec)
+	}
+
+	var rfis []models.ProjectRFI
+	if clone.IncludeRFIs {
+		// Keep the questions in the order they were asked
+		sort.Slice(source.RFIs, func(i, j int) bool { return source.RFIs[i].RFIID < source.RFIs[j].RFIID })
+		for _, rfi := range source.RFIs {
+			rfis = append(rfis, models.ProjectRFI{QuestionText: rfi.QuestionText})
+		}
+	}
+
+	if err := s.CreateProjectWithDetails(actor, project, specifications, rfis); err != nil {
+		return nil, err
+	}
+
+	created, err := s.projectRepo.FindByID(actor.OrganizationID, project.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
 }
 
-func (s *projectService) ListProjects() ([]models.Project, error) {
-	return s.projectRepo.List()
+// UpdateProject changes the project's details if it is still at the version
+// the editor last saw. On ErrProjectConflict the current project is returned
+// so that the editor can merge their changes into it.
+func (s *projectService) UpdateProject(actor Actor, projectID uint64, update ProjectUpdate, version int) (*models.Project, error) {
+	if err := s.access.Authorize(actor, projectID, AccessEdit); err != nil {
+		return nil, err
+	}
+
+	project, err := s.projectRepo.FindByID(actor.OrganizationID, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if project.Version != version {
+		return project, ErrProjectConflict
+	}
+	if project.ArchivedAt != nil {
+		return nil, errProjectArchived
+	}
+
+	if update.ProjectName != nil {
+		name := strings.TrimSpace(*update.ProjectName)
+		if name == "" {
+			return nil, errors.New("project name cannot be empty")
+		}
+		project.ProjectName = name
+	}
+	if update.CompanyName != nil {
+		project.CompanyName = strings.TrimSpace(*update.CompanyName)
+	}
+	if update.CompanyAddress != nil {
+		project.CompanyAddress = strings.TrimSpace(*update.CompanyAddress)
+	}
+	if update.ProjectType != nil {
+		switch *update.ProjectType {
+		case models.TypeWindows, models.TypeDoors:
+			project.ProjectType = *update.ProjectType
+		default:
+			return nil, errors.New("invalid project type")
+		}
+	}
+	project.LastUpdatedBy = &actor.UserID
+
+	saved, err := s.projectRepo.UpdateDetails(actor.OrganizationID, project, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload so that the caller gets the new version, and on a conflict the
+	// changes that won
+	current, err := s.projectRepo.FindByID(actor.OrganizationID, projectID)
+	if err != nil {
+		return nil, ErrProjectNotFound
+	}
+	if !saved {
+		return current, ErrProjectConflict
+	}
+	return current, nil
 }
 
-func (s *projectService) UpdateProjectStatus(projectID uint64, status models.ProjectStatus, updatedBy uint64) error {
+// UpdateProjectStatus moves the project to another status. Only the
+// configured transitions are allowed, moving backwards needs a reason, and
+// completing a project needs its work to be done.
+func (s *projectService) UpdateProjectStatus(actor Actor, projectID uint64, status models.ProjectStatus, reason string) error {
+	if err := s.access.Authorize(actor, projectID, AccessEdit); err != nil {
+		return err
+	}
+
 	// Validate status
-	switch status {
-	case models.StatusNotYetStarted, models.StatusProgress, models.StatusCompleted:
-		// Valid status
-	default:
+	if _, ok := projectStatusOrder[status]; !ok {
 		return errors.New("invalid project status")
 	}
 
-	return s.projectRepo.UpdateStatus(projectID, status, updatedBy)
+	project, err := s.projectRepo.FindByID(actor.OrganizationID, projectID)
+	if err != nil {
+		return ErrProjectNotFound
+	}
+	if project.ArchivedAt != nil {
+		return errProjectArchived
+	}
+	from := project.ProjectStatus
+	if status == from {
+		return fmt.Errorf("project is already %s", status)
+	}
+	if !s.canMoveTo(from, status) {
+		return fmt.Errorf("project cannot move from %s to %s", from, status)
+	}
+
+	change := &models.ProjectStatusChange{
+		ProjectID:  projectID,
+		FromStatus: from,
+		ToStatus:   status,
+		ChangedBy:  actor.UserID,
+	}
+	if reason = strings.TrimSpace(reason); reason != "" {
+		change.Reason = &reason
+	}
+	if projectStatusOrder[status] < projectStatusOrder[from] && change.Reason == nil {
+		return fmt.Errorf("a reason is required to move a project back to %s", status)
+	}
+
+	guard := repositories.CompletionGuard{
+		RequireSpecification: s.cfg.Project.CompleteRequiresSpecification,
+		RequireAnsweredRFIs:  s.cfg.Project.CompleteRequiresAnsweredRFIs,
+	}
+	updated, err := s.projectRepo.UpdateStatus(actor.OrganizationID, change, guard)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrProjectConflict
+	}
+	return nil
+}
+
+func (s *projectService) canMoveTo(from, to models.ProjectStatus) bool {
+	for _, allowed := range s.cfg.Project.StatusTransitions[string(from)] {
+		if allowed == string(to) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *projectService) ListStatusHistory(actor Actor, projectID uint64) ([]models.ProjectStatusChange, error) {
+	if err := s.access.Authorize(actor, projectID, AccessView); err != nil {
+		return nil, err
+	}
+
+	return s.projectRepo.ListStatusHistory(actor.OrganizationID, projectID)
+}
+
+// ArchiveProject hides a completed project from default project listings.
+func (s *projectService) ArchiveProject(actor Actor, projectID uint64) error {
+	if err := s.access.Authorize(actor, projectID, AccessEdit); err != nil {
+		return err
+	}
+
+	archived, err := s.projectRepo.Archive(actor.OrganizationID, projectID, actor.UserID)
+	if err != nil {
+		return err
+	}
+	if !archived {
+		return errors.New("only completed projects that are not archived yet can be archived")
+	}
+	return nil
+}
+
+func (s *projectService) UnarchiveProject(actor Actor, projectID uint64) error {
+	if err := s.access.Authorize(actor, projectID, AccessEdit); err != nil {
+		return err
+	}
+
+	unarchived, err := s.projectRepo.Unarchive(actor.OrganizationID, projectID, actor.UserID)
+	if err != nil {
+		return err
+	}
+	if !unarchived {
+		return errors.New("project is not archived")
+	}
+	return nil
+}
+
+// DeleteProject soft-deletes the project. It can be restored until the
+// retention period has passed and it is purged.
+func (s *projectService) DeleteProject(actor Actor, projectID uint64) error {
+	if err := s.access.Authorize(actor, projectID, AccessManage); err != nil {
+		return err
+	}
+
+	deleted, err := s.projectRepo.Delete(actor.OrganizationID, projectID, actor.UserID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrProjectNotFound
+	}
+	return nil
+}
+
+func (s *projectService) ListDeletedProjects(actor Actor) ([]models.Project, error) {
+	return s.projectRepo.ListDeleted(actor.OrganizationID)
+}
+
+func (s *projectService) RestoreProject(actor Actor, projectID uint64) error {
+	restored, err := s.projectRepo.Restore(actor.OrganizationID, projectID)
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrProjectNotFound
+	}
+	return nil
+}
+
+func (s *projectService) ListMembers(actor Actor, projectID uint64) ([]models.ProjectMember, error) {
+	if err := s.access.Authorize(actor, projectID, AccessView); err != nil {
+		return nil, err
+	}
+
+	return s.memberRepo.ListByProject(projectID)
+}
+
+func (s *projectService) AddMember(actor Actor, projectID, userID uint64, role models.ProjectMemberRole) (*models.ProjectMember, error) {
+	if err := s.access.Authorize(actor, projectID, AccessManage); err != nil {
+		return nil, err
+	}
+
+	if _, ok := memberAccess[role]; !ok {
+		return nil, errors.New("invalid member role")
+	}
+
+	// Only users of the project's own organization can be added
+	if _, err := s.userRepo.FindInOrganization(actor.OrganizationID, userID); err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if existing, _ := s.memberRepo.Find(projectID, userID); existing != nil {
+		return nil, errors.New("user is already a member of this project")
+	}
+
+	member := &models.ProjectMember{
+		ProjectID:  projectID,
+		UserID:     userID,
+		MemberRole: role,
+		AddedBy:    &actor.UserID,
+	}
+	if err := s.memberRepo.Create(member); err != nil {
+		return nil, err
+	}
+	return member, nil
 }
 
-func (s *projectService) DeleteProject(projectID uint64, userRole models.UserRole) error {
-	if userRole != models.RoleAdmin {
-		return errors.New("only admins can delete projects")
+func (s *projectService) UpdateMemberRole(actor Actor, projectID, userID uint64, role models.ProjectMemberRole) error {
+	if err := s.access.Authorize(actor, projectID, AccessManage); err != nil {
+		return err
 	}
 
-	return s.projectRepo.Delete(projectID)
+	if _, ok := memberAccess[role]; !ok {
+		return errors.New("invalid member role")
+	}
+
+	member, err := s.memberRepo.Find(projectID, userID)
+	if err != nil {
+		return errors.New("user is not a member of this project")
+	}
+
+	if member.MemberRole == models.MemberOwner && role != models.MemberOwner {
+		if err := s.ensureAnotherOwner(projectID); err != nil {
+			return err
+		}
+	}
+
+	return s.memberRepo.UpdateRole(projectID, userID, role)
+}
+
+func (s *projectService) RemoveMember(actor Actor, projectID, userID uint64) error {
+	if err := s.access.Authorize(actor, projectID, AccessManage); err != nil {
+		return err
+	}
+
+	member, err := s.memberRepo.Find(projectID, userID)
+	if err != nil {
+		return errors.New("user is not a member of this project")
+	}
+
+	if member.MemberRole == models.MemberOwner {
+		if err := s.ensureAnotherOwner(projectID); err != nil {
+			return err
+		}
+	}
+
+	return s.memberRepo.Delete(projectID, userID)
+}
+
+// ensureAnotherOwner stops the last owner from being removed or demoted,
+// which would leave nobody able to manage the project.
+func (s *projectService) ensureAnotherOwner(projectID uint64) error {
+	owners, err := s.memberRepo.CountOwners(projectID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errors.New("a project must keep at least one owner")
+	}
+	return nil
 }
\ No newline at end of file
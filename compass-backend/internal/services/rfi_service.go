@@ -8,45 +8,67 @@ import (
 )
 
 type RFIService interface {
-	CreateRFI(rfi *models.ProjectRFI) error
-	GetRFI(rfiID uint64) (*models.ProjectRFI, error)
-	GetProjectRFIs(projectID uint64) ([]models.ProjectRFI, error)
-	AnswerRFI(rfiID uint64, answer models.AnswerValue, answeredBy uint64) error
+	CreateRFI(actor Actor, rfi *models.ProjectRFI) error
+	GetRFI(actor Actor, rfiID uint64) (*models.ProjectRFI, error)
+	GetProjectRFIs(actor Actor, projectID uint64) ([]models.ProjectRFI, error)
+	AnswerRFI(actor Actor, rfiID uint64, answer models.AnswerValue) error
 }
 
 type rfiService struct {
 	rfiRepo repositories.RFIRepository
+	access  ProjectAccess
 }
 
-func NewRFIService(rfiRepo repositories.RFIRepository) RFIService {
+func NewRFIService(rfiRepo repositories.RFIRepository, access ProjectAccess) RFIService {
 	return &rfiService{
 		rfiRepo: rfiRepo,
+		access:  access,
 	}
 }
 
-func (s *rfiService) CreateRFI(rfi *models.ProjectRFI) error {
+func (s *rfiService) CreateRFI(actor Actor, rfi *models.ProjectRFI) error {
+	if err := s.access.AuthorizeContentChange(actor, rfi.ProjectID, AccessEdit); err != nil {
+		return err
+	}
+
 	return s.rfiRepo.Create(rfi)
 }
 
-func (s *rfiService) GetRFI(rfiID uint64) (*models.ProjectRFI, error) {
-	return s.rfiRepo.FindByID(rfiID)
+func (s *rfiService) GetRFI(actor Actor, rfiID uint64) (*models.ProjectRFI, error) {
+	rfi, err := s.rfiRepo.FindByID(actor.OrganizationID, rfiID)
+	if err != nil {
+		return nil, errors.New("RFI not found")
+	}
+
+	if err := s.access.Authorize(actor, rfi.ProjectID, AccessView); err != nil {
+		return nil, err
+	}
+	return rfi, nil
 }
 
-func (s *rfiService) GetProjectRFIs(projectID uint64) ([]models.ProjectRFI, error) {
-	return s.rfiRepo.FindByProjectID(projectID)
+func (s *rfiService) GetProjectRFIs(actor Actor, projectID uint64) ([]models.ProjectRFI, error) {
+	if err := s.access.Authorize(actor, projectID, AccessView); err != nil {
+		return nil, err
+	}
+
+	return s.rfiRepo.FindByProjectID(actor.OrganizationID, projectID)
 }
 
-func (s *rfiService) AnswerRFI(rfiID uint64, answer models.AnswerValue, answeredBy uint64) error {
+func (s *rfiService) AnswerRFI(actor Actor, rfiID uint64, answer models.AnswerValue) error {
 	// Validate answer
 	if answer != models.AnswerYes && answer != models.AnswerNo {
 		return errors.New("invalid answer value")
 	}
 
 	// Check if RFI exists
-	_, err := s.rfiRepo.FindByID(rfiID)
+	rfi, err := s.rfiRepo.FindByID(actor.OrganizationID, rfiID)
 	if err != nil {
 		return errors.New("RFI not found")
 	}
 
-	return s.rfiRepo.Answer(rfiID, answer, answeredBy)
+	if err := s.access.AuthorizeContentChange(actor, rfi.ProjectID, AccessAnswer); err != nil {
+		return err
+	}
+
+	return s.rfiRepo.Answer(actor.OrganizationID, rfiID, answer, actor.UserID)
 }
\ No newline at end of file
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+type RoleService interface {
+	ListRoles() ([]models.Role, error)
+	GetRole(roleID uint64) (*models.Role, error)
+	CreateRole(role *models.Role) error
+	UpdateRole(roleID uint64, description string, permissions []models.Permission) (*models.Role, error)
+	DeleteRole(roleID uint64) error
+	ListPermissions() ([]models.PermissionDefinition, error)
+	AssignRole(orgID, userID uint64, role models.UserRole) error
+}
+
+type roleService struct {
+	roleRepo         repositories.RoleRepository
+	userRepo         repositories.UserRepository
+	authStateService AuthStateService
+}
+
+func NewRoleService(roleRepo repositories.RoleRepository, userRepo repositories.UserRepository, authStateService AuthStateService) RoleService {
+	return &roleService{
+		roleRepo:         roleRepo,
+		userRepo:         userRepo,
+		authStateService: authStateService,
+	}
+}
+
+func (s *roleService) ListRoles() ([]models.Role, error) {
+	return s.roleRepo.List()
+}
+
+func (s *roleService) GetRole(roleID uint64) (*models.Role, error) {
+	return s.roleRepo.FindByID(roleID)
+}
+
+func (s *roleService) CreateRole(role *models.Role) error {
+	if !roleNamePattern.MatchString(string(role.Name)) {
+		return errors.New("role name must be 2-50 lowercase letters, digits, '-' or '_' and start with a letter")
+	}
+
+	if existing, _ := s.roleRepo.FindByName(role.Name); existing != nil {
+		return errors.New("role already exists")
+	}
+
+	if err := s.validatePermissions(role.Permissions); err != nil {
+		return err
+	}
+
+	role.BuiltIn = false
+	return s.roleRepo.Create(role)
+}
+
+func (s *roleService) UpdateRole(roleID uint64, description string, permissions []models.Permission) (*models.Role, error) {
+	role, err := s.roleRepo.FindByID(roleID)
+	if err != nil {
+		return nil, errors.New("role not found")
+	}
+
+	// Editing the admin roles could lock every administrator out
+	if role.Name == models.RoleAdmin || role.Name == models.RoleSuperAdmin {
+		return nil, fmt.Errorf("the %s role cannot be modified", role.Name)
+	}
+
+	if err := s.validatePermissions(permissions); err != nil {
+		return nil, err
+	}
+
+	role.Description = description
+	role.Permissions = permissions
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+
+	// Cached permissions of every holder of this role are now stale
+	s.authStateService.InvalidateAll()
+	return role, nil
+}
+
+func (s *roleService) DeleteRole(roleID uint64) error {
+	role, err := s.roleRepo.FindByID(roleID)
+	if err != nil {
+		return errors.New("role not found")
+	}
+
+	if role.BuiltIn {
+		return errors.New("built-in roles cannot be deleted")
+	}
+
+	count, err := s.roleRepo.CountUsers(role.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("role is assigned to %d user(s)", count)
+	}
+
+	return s.roleRepo.Delete(roleID)
+}
+
+func (s *roleService) ListPermissions() ([]models.PermissionDefinition, error) {
+	return s.roleRepo.ListPermissions()
+}
+
+func (s *roleService) AssignRole(orgID, userID uint64, role models.UserRole) error {
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.ErasedAt != nil {
+		return ErrUserErased
+	}
+
+	// Super-admins sit outside every organization and are only seeded
+	if role == models.RoleSuperAdmin {
+		return errors.New("the super_admin role cannot be assigned to organization users")
+	}
+
+	if _, err := s.roleRepo.FindByName(role); err != nil {
+		return errors.New("role not found")
+	}
+
+	if err := s.userRepo.UpdateRole(userID, role); err != nil {
+		return err
+	}
+
+	// Tokens issued under the old role must not outlive it
+	return s.authStateService.RevokeTokens(userID)
+}
+
+func (s *roleService) validatePermissions(permissions []models.Permission) error {
+	known, err := s.roleRepo.ListPermissions()
+	if err != nil {
+		return err
+	}
+
+	valid := make(map[models.Permission]bool, len(known))
+	for _, p := range known {
+		valid[p.Name] = true
+	}
+
+	for _, p := range permissions {
+		if !valid[p] {
+			return fmt.Errorf("unknown permission: %s", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"compass-backend/config"
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionEnded    = errors.New("session has ended")
+)
+
+// sessionTouchInterval bounds how often a session's last-seen time is written
+// while it is being used.
+const sessionTouchInterval = time.Minute
+
+type SessionService interface {
+	StartSession(user *models.User, client ClientInfo) (*models.Session, error)
+	RefreshSession(sessionID string, client ClientInfo) error
+	ValidateSession(userID uint64, sessionID string, client ClientInfo) error
+	EndSession(sessionID string) error
+	ListSessions(userID uint64, currentSessionID string) ([]models.Session, error)
+	RevokeSession(userID uint64, sessionID string) error
+	RevokeAllSessions(userID uint64) error
+	ListUserSessions(orgID, userID uint64) ([]models.Session, error)
+	RevokeUserSession(orgID, userID uint64, sessionID string) error
+	RevokeAllUserSessions(orgID, userID uint64) error
+}
+
+type sessionEntry struct {
+	userID    uint64
+	active    bool
+	expiresAt time.Time
+	checkedAt time.Time
+}
+
+type sessionService struct {
+	sessionRepo      repositories.SessionRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	userRepo         repositories.UserRepository
+	cfg              *config.Config
+
+	mu      sync.Mutex
+	cache   map[string]sessionEntry
+	sweptAt time.Time
+}
+
+func NewSessionService(sessionRepo repositories.SessionRepository, refreshTokenRepo repositories.RefreshTokenRepository, userRepo repositories.UserRepository, cfg *config.Config) SessionService {
+	return &sessionService{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		userRepo:         userRepo,
+		cfg:              cfg,
+		cache:            make(map[string]sessionEntry),
+	}
+}
+
+func (s *sessionService) StartSession(user *models.User, client ClientInfo) (*models.Session, error) {
+	sessionID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		SessionID:  sessionID,
+		UserID:     user.UserID,
+		IPAddress:  client.IP,
+		UserAgent:  truncate(client.UserAgent, 512),
+		Device:     describeDevice(client.UserAgent),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.cfg.JWT.RefreshTokenDuration),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// RefreshSession extends a session along with the refresh token that was just
+// issued for it.
+func (s *sessionService) RefreshSession(sessionID string, client ClientInfo) error {
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.JWT.RefreshTokenDuration)
+	if err := s.sessionRepo.Touch(sessionID, client.IP, now, &expiresAt); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, sessionID)
+	s.mu.Unlock()
+	return nil
+}
+
+// ValidateSession checks that the session an access token was issued under is
+// still active and records that it was used. Results are cached for the same
+// time as the auth state, so revocations made by another instance take effect
+// within that window.
+func (s *sessionService) ValidateSession(userID uint64, sessionID string, client ClientInfo) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[sessionID]
+	s.mu.Unlock()
+
+	if !ok || now.After(entry.checkedAt.Add(authStateCacheTTL)) {
+		session, err := s.sessionRepo.FindByID(sessionID)
+		if err != nil {
+			return ErrSessionEnded
+		}
+		entry = sessionEntry{
+			userID:    session.UserID,
+			active:    session.RevokedAt == nil,
+			expiresAt: session.ExpiresAt,
+			checkedAt: now,
+		}
+
+		// Only write the last-seen time once in a while
+		if entry.active && now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+			if err := s.sessionRepo.Touch(sessionID, client.IP, now, nil); err != nil {
+				log.Printf("Failed to record activity for session %s: %v", sessionID, err)
+			}
+		}
+
+		s.mu.Lock()
+		s.sweepCache(now)
+		s.cache[sessionID] = entry
+		s.mu.Unlock()
+	}
+
+	if !entry.active || entry.userID != userID || now.After(entry.expiresAt) {
+		return ErrSessionEnded
+	}
+	return nil
+}
+
+// sweepCache drops entries that would be looked up again anyway, so sessions
+// that stop being used do not stay in memory. It runs at most once per cache
+// TTL and must be called with mu held.
+func (s *sessionService) sweepCache(now time.Time) {
+	if now.Sub(s.sweptAt) < authStateCacheTTL {
+		return
+	}
+	for id, entry := range s.cache {
+		if now.After(entry.checkedAt.Add(authStateCacheTTL)) || now.After(entry.expiresAt) {
+			delete(s.cache, id)
+		}
+	}
+	s.sweptAt = now
+}
+
+// EndSession signs out of the session an access token was issued under. Ending
+// a session that has already ended is not an error.
+func (s *sessionService) EndSession(sessionID string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		return nil
+	}
+	if err := s.RevokeSession(session.UserID, sessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s *sessionService) ListSessions(userID uint64, currentSessionID string) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.ListActiveByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions and the refresh tokens issued
+// for it.
+func (s *sessionService) RevokeSession(userID uint64, sessionID string) error {
+	revoked, err := s.sessionRepo.Revoke(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+
+	s.mu.Lock()
+	delete(s.cache, sessionID)
+	s.mu.Unlock()
+
+	return s.refreshTokenRepo.RevokeFamily(sessionID)
+}
+
+// RevokeAllSessions signs the user out everywhere, including the session the
+// request was made from.
+func (s *sessionService) RevokeAllSessions(userID uint64) error {
+	if err := s.sessionRepo.RevokeAllForUser(userID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for id, entry := range s.cache {
+		if entry.userID == userID {
+			delete(s.cache, id)
+		}
+	}
+	s.mu.Unlock()
+
+	return s.refreshTokenRepo.RevokeAllForUser(userID)
+}
+
+func (s *sessionService) ListUserSessions(orgID, userID uint64) ([]models.Session, error) {
+	if _, err := s.userRepo.FindInOrganization(orgID, userID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	return s.ListSessions(userID, "")
+}
+
+func (s *sessionService) RevokeUserSession(orgID, userID uint64, sessionID string) error {
+	if _, err := s.userRepo.FindInOrganization(orgID, userID); err != nil {
+		return ErrUserNotFound
+	}
+	return s.RevokeSession(userID, sessionID)
+}
+
+func (s *sessionService) RevokeAllUserSessions(orgID, userID uint64) error {
+	if _, err := s.userRepo.FindInOrganization(orgID, userID); err != nil {
+		return ErrUserNotFound
+	}
+	return s.RevokeAllSessions(userID)
+}
+
+// describeDevice turns a user agent into a short label such as
+// "Firefox on Windows".
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	platform := ""
+	for _, p := range []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+
+	if platform == "" {
+		return browser
+	}
+	return browser + " on " + platform
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
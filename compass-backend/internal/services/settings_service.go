@@ -0,0 +1,58 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"compass-backend/internal/models"
+	"compass-backend/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SecurityPolicy holds the admin-managed security settings.
+type SecurityPolicy struct {
+	RequireAdminMFA bool `json:"require_admin_mfa"`
+}
+
+type SettingsService interface {
+	GetSecurityPolicy(orgID uint64) (*SecurityPolicy, error)
+	UpdateSecurityPolicy(orgID uint64, policy *SecurityPolicy, updatedBy uint64) error
+}
+
+type settingsService struct {
+	settingsRepo repositories.SettingsRepository
+}
+
+func NewSettingsService(settingsRepo repositories.SettingsRepository) SettingsService {
+	return &settingsService{
+		settingsRepo: settingsRepo,
+	}
+}
+
+// GetSecurityPolicy returns the organization's policy. Settings that were never
+// saved keep their defaults.
+func (s *settingsService) GetSecurityPolicy(orgID uint64) (*SecurityPolicy, error) {
+	policy := &SecurityPolicy{}
+
+	// Only a setting that does not exist falls back to its default; any other
+	// failure must not quietly switch the policy off
+	setting, err := s.settingsRepo.Get(orgID, models.SettingRequireAdminMFA)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return policy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	policy.RequireAdminMFA, err = strconv.ParseBool(setting.SettingValue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s setting: %w", models.SettingRequireAdminMFA, err)
+	}
+	return policy, nil
+}
+
+func (s *settingsService) UpdateSecurityPolicy(orgID uint64, policy *SecurityPolicy, updatedBy uint64) error {
+	return s.settingsRepo.Set(orgID, models.SettingRequireAdminMFA, strconv.FormatBool(policy.RequireAdminMFA), updatedBy)
+}
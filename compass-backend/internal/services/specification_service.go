@@ -6,30 +6,44 @@ import (
 )
 
 type SpecificationService interface {
-	CreateSpecification(spec *models.ProjectSpecification) error
-	GetProjectSpecifications(projectID uint64) ([]models.ProjectSpecification, error)
-	GetLatestSpecification(projectID uint64) (*models.ProjectSpecification, error)
+	CreateSpecification(actor Actor, spec *models.ProjectSpecification) error
+	GetProjectSpecifications(actor Actor, projectID uint64) ([]models.ProjectSpecification, error)
+	GetLatestSpecification(actor Actor, projectID uint64) (*models.ProjectSpecification, error)
 }
 
 type specificationService struct {
 	specRepo repositories.SpecificationRepository
+	access   ProjectAccess
 }
 
-func NewSpecificationService(specRepo repositories.SpecificationRepository) SpecificationService {
+func NewSpecificationService(specRepo repositories.SpecificationRepository, access ProjectAccess) SpecificationService {
 	return &specificationService{
 		specRepo: specRepo,
+		access:   access,
 	}
 }
 
-func (s *specificationService) CreateSpecification(spec *models.ProjectSpecification) error {
+func (s *specificationService) CreateSpecification(actor Actor, spec *models.ProjectSpecification) error {
+	if err := s.access.AuthorizeContentChange(actor, spec.ProjectID, AccessEdit); err != nil {
+		return err
+	}
+
 	// Version number is automatically handled in the model's BeforeCreate hook
 	return s.specRepo.Create(spec)
 }
 
-func (s *specificationService) GetProjectSpecifications(projectID uint64) ([]models.ProjectSpecification, error) {
-	return s.specRepo.FindByProjectID(projectID)
+func (s *specificationService) GetProjectSpecifications(actor Actor, projectID uint64) ([]models.ProjectSpecification, error) {
+	if err := s.access.Authorize(actor, projectID, AccessView); err != nil {
+		return nil, err
+	}
+
+	return s.specRepo.FindByProjectID(actor.OrganizationID, projectID)
 }
 
-func (s *specificationService) GetLatestSpecification(projectID uint64) (*models.ProjectSpecification, error) {
-	return s.specRepo.FindLatestByProjectID(projectID)
+func (s *specificationService) GetLatestSpecification(actor Actor, projectID uint64) (*models.ProjectSpecification, error) {
+	if err := s.access.Authorize(actor, projectID, AccessView); err != nil {
+		return nil, err
+	}
+
+	return s.specRepo.FindLatestByProjectID(actor.OrganizationID, projectID)
 }
\ No newline at end of file
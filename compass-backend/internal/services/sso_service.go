@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"compass-backend/config"
+	"compass-backend/internal/models"
+	"compass-backend/internal/oidc"
+	"compass-backend/internal/repositories"
+	"compass-backend/internal/utils"
+)
+
+var (
+	ErrSSOFailed           = errors.New("single sign-on failed")
+	ErrSSOAccountPending   = errors.New("your account has been created and is awaiting activation by an administrator")
+	ErrSSOAccountNotLinked = errors.New("no Compass account exists for this identity")
+)
+
+// SSOLogin is a started SSO login. The client sends the user to
+// AuthorizationURL and returns the state together with the code it receives.
+type SSOLogin struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+type SSOService interface {
+	BeginLogin(ctx context.Context) (*SSOLogin, error)
+	CompleteLogin(ctx context.Context, code, state string, client ClientInfo) (*SignInResult, error)
+}
+
+type ssoService struct {
+	provider    *oidc.Provider
+	oidcRepo    repositories.OIDCRepository
+	userRepo    repositories.UserRepository
+	orgRepo     repositories.OrganizationRepository
+	authService AuthService
+	cfg         *config.Config
+}
+
+func NewSSOService(provider *oidc.Provider, oidcRepo repositories.OIDCRepository, userRepo repositories.UserRepository, orgRepo repositories.OrganizationRepository, authService AuthService, cfg *config.Config) SSOService {
+	return &ssoService{
+		provider:    provider,
+		oidcRepo:    oidcRepo,
+		userRepo:    userRepo,
+		orgRepo:     orgRepo,
+		authService: authService,
+		cfg:         cfg,
+	}
+}
+
+// BeginLogin starts an authorization code flow with PKCE. The state, nonce
+// and code verifier are kept server-side until the login is completed.
+func (s *ssoService) BeginLogin(ctx context.Context) (*SSOLogin, error) {
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &models.OIDCAuthRequest{
+		StateHash:    utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(s.cfg.OIDC.RequestDuration),
+	}
+	if err := s.oidcRepo.CreateAuthRequest(req); err != nil {
+		return nil, err
+	}
+
+	return &SSOLogin{AuthorizationURL: authURL, State: state}, nil
+}
+
+// CompleteLogin redeems the authorization code, verifies the ID token and
+// signs in the linked user.
+func (s *ssoService) CompleteLogin(ctx context.Context, code, state string, client ClientInfo) (*SignInResult, error) {
+	req, err := s.oidcRepo.ConsumeAuthRequest(utils.HashToken(state))
+	if err != nil {
+		return nil, errors.New("invalid or expired login state")
+	}
+
+	rawIDToken, err := s.provider.Exchange(ctx, code, req.CodeVerifier)
+	if err != nil {
+		log.Printf("SSO code exchange failed: %v", err)
+		return nil, ErrSSOFailed
+	}
+
+	claims, err := s.provider.VerifyIDToken(ctx, rawIDToken, req.Nonce)
+	if err != nil {
+		log.Printf("SSO ID token rejected: %v", err)
+		return nil, ErrSSOFailed
+	}
+
+	user, err := s.resolveUser(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.authService.SignInExternal(user, client)
+}
+
+// resolveUser finds the user an identity belongs to. Identities seen before
+// are matched by issuer and subject; new ones are linked to the account with
+// the same verified email or, if enabled, provisioned as a pending account.
+func (s *ssoService) resolveUser(claims *oidc.IDTokenClaims) (*models.User, error) {
+	issuer := claims.Issuer
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	now := time.Now()
+
+	if identity, err := s.oidcRepo.FindIdentity(issuer, claims.Subject); err == nil {
+		user, err := s.userRepo.FindByID(identity.UserID)
+		if err != nil {
+			return nil, ErrSSOFailed
+		}
+		if err := s.oidcRepo.TouchIdentity(identity.IdentityID, email, now); err != nil {
+			log.Printf("Failed to update identity %d: %v", identity.IdentityID, err)
+		}
+		if user.AccountStatus == models.StatusPending {
+			return nil, ErrSSOAccountPending
+		}
+		return user, nil
+	}
+
+	// Linking by email is only safe when the provider vouches for the address
+	if email == "" || claims.EmailVerified == nil || !*claims.EmailVerified {
+		return nil, errors.New("the identity provider did not supply a verified email address")
+	}
+
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if !s.cfg.OIDC.AutoProvision {
+			return nil, ErrSSOAccountNotLinked
+		}
+		if user, err = s.provisionUser(email, claims.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if user.IsServiceAccount {
+		return nil, ErrSSOAccountNotLinked
+	}
+
+	identity := &models.UserIdentity{
+		UserID:      user.UserID,
+		Issuer:      issuer,
+		Subject:     claims.Subject,
+		Email:       email,
+		LastLoginAt: &now,
+	}
+	if err := s.oidcRepo.CreateIdentity(identity); err != nil {
+		return nil, err
+	}
+
+	if user.AccountStatus == models.StatusPending {
+		return nil, ErrSSOAccountPending
+	}
+	return user, nil
+}
+
+// provisionUser creates a pending account for a first-time SSO user. An admin
+// has to activate it before the user can sign in.
+func (s *ssoService) provisionUser(email, name string) (*models.User, error) {
+	org, err := s.orgRepo.FindBySlug(s.cfg.OIDC.ProvisionOrganization)
+	if err != nil {
+		return nil, fmt.Errorf("provisioning organization %q not found", s.cfg.OIDC.ProvisionOrganization)
+	}
+
+	if name == "" {
+		name = email
+	}
+
+	user := &models.User{
+		OrganizationID: &org.OrganizationID,
+		FullName:       name,
+		Email:          email,
+		Role:           models.UserRole(s.cfg.OIDC.ProvisionRole),
+		AccountStatus:  models.StatusPending,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Provisioned pending user %s from single sign-on", email)
+	return user, nil
+}
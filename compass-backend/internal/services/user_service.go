@@ -2,84 +2,300 @@ package services
 
 import (
 	"errors"
+	"log"
+	"strings"
+	"time"
 
 	"compass-backend/internal/models"
 	"compass-backend/internal/repositories"
 	"compass-backend/internal/utils"
 )
 
+var (
+	// ErrUserNotFound is returned when a user does not exist in the caller's
+	// organization.
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrLastAdmin is returned when a change would leave an organization
+	// without an active admin.
+	ErrLastAdmin = repositories.ErrLastAdmin
+
+	// ErrUserErased is returned when changing an account whose personal data
+	// was erased. Such accounts have no credentials and must stay disabled.
+	ErrUserErased = errors.New("erased users cannot be changed")
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+	userExportBatchSize = 500
+)
+
+// UserPage is one page of a user listing.
+type UserPage struct {
+	Users      []models.User
+	Total      int64
+	NextCursor string
+}
+
+// UserUpdate holds the fields an admin wants to change on a user. Nil fields
+// are left as they are.
+type UserUpdate struct {
+	FullName *string
+	Role     *models.UserRole
+}
+
 type UserService interface {
 	CreateUser(user *models.User, password string, invitedBy uint64) error
-	UpdateUserStatus(userID uint64, status models.AccountStatus) error
-	GetUser(userID uint64) (*models.User, error)
-	ListUsers() ([]models.User, error)
+	UpdateUserStatus(orgID, userID uint64, status models.AccountStatus) error
+	GetUser(orgID, userID uint64) (*models.User, error)
+	UpdateUser(orgID, userID uint64, update UserUpdate) (*models.User, error)
+	ListUsers(filter repositories.UserFilter, cursor string) (*UserPage, error)
+	ExportUsers(filter repositories.UserFilter, write func(user *models.User) error) error
 	SetPassword(userID uint64, password string) error
 	ChangePassword(userID uint64, currentPassword, newPassword string) error
-	ResetPassword(userID uint64, newPassword string) error
+	ResetPassword(orgID, userID uint64, newPassword string) error
+	UnlockUser(orgID, userID uint64) error
 }
 
 type userService struct {
-	userRepo repositories.UserRepository
+	userRepo         repositories.UserRepository
+	roleRepo         repositories.RoleRepository
+	roleService      RoleService
+	authStateService AuthStateService
+	inviteService    InviteService
+	passwordPolicy   PasswordPolicy
 }
 
-func NewUserService(userRepo repositories.UserRepository) UserService {
+func NewUserService(userRepo repositories.UserRepository, roleRepo repositories.RoleRepository, roleService RoleService, authStateService AuthStateService, inviteService InviteService, passwordPolicy PasswordPolicy) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		roleRepo:         roleRepo,
+		roleService:      roleService,
+		authStateService: authStateService,
+		inviteService:    inviteService,
+		passwordPolicy:   passwordPolicy,
 	}
 }
 
+// CreateUser adds a user to the organization set on user.OrganizationID.
 func (s *userService) CreateUser(user *models.User, password string, invitedBy uint64) error {
-	// Check if email already exists
+	if user.OrganizationID == nil {
+		return errors.New("user must belong to an organization")
+	}
+
+	// Email addresses identify users at sign-in, so they are unique across
+	// every organization
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
 	existingUser, _ := s.userRepo.FindByEmail(user.Email)
 	if existingUser != nil {
 		return errors.New("email already exists")
 	}
 
+	if user.Role == models.RoleSuperAdmin {
+		return errors.New("the super_admin role cannot be assigned to organization users")
+	}
+
+	if _, err := s.roleRepo.FindByName(user.Role); err != nil {
+		return errors.New("role not found")
+	}
+
 	user.InvitedBy = &invitedBy
 	user.AccountStatus = models.StatusPending
 
 	// Hash password if provided
 	if password != "" {
-		hashedPassword, err := utils.HashPassword(password)
+		hashedPassword, err := s.passwordPolicy.Hash(user, password)
 		if err != nil {
 			return err
 		}
+		now := time.Now()
 		user.PasswordHash = &hashedPassword
+		user.PasswordChangedAt = &now
 		user.AccountStatus = models.StatusActive
 	}
 
-	return s.userRepo.Create(user)
+	if err := s.userRepo.Create(user); err != nil {
+		// A concurrent request may have taken the address since the check
+		// above, in which case the unique index refused this one
+		if existing, _ := s.userRepo.FindByEmail(user.Email); existing != nil {
+			return errors.New("email already exists")
+		}
+		return err
+	}
+
+	if user.PasswordHash != nil {
+		if err := s.passwordPolicy.Remember(user.UserID, *user.PasswordHash); err != nil {
+			return err
+		}
+	}
+
+	// Users without a password activate their account through an invite link.
+	// The account already exists at this point, so a failed delivery is only
+	// logged and can be retried with a resend.
+	if user.AccountStatus == models.StatusPending {
+		if err := s.inviteService.SendInvite(user, invitedBy); err != nil {
+			log.Printf("Failed to invite user %s: %v", user.Email, err)
+		}
+	}
+
+	return nil
 }
 
-func (s *userService) UpdateUserStatus(userID uint64, status models.AccountStatus) error {
-	return s.userRepo.UpdateStatus(userID, status)
+func (s *userService) UpdateUserStatus(orgID, userID uint64, status models.AccountStatus) error {
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+
+	if user.ErasedAt != nil {
+		return ErrUserErased
+	}
+
+	if err := s.userRepo.UpdateStatus(userID, status); err != nil {
+		return err
+	}
+
+	// Tokens issued while the account was active must stop working at once
+	if status != models.StatusActive {
+		return s.authStateService.RevokeTokens(userID)
+	}
+	s.authStateService.Invalidate(userID)
+	return nil
 }
 
-func (s *userService) GetUser(userID uint64) (*models.User, error) {
-	return s.userRepo.FindByID(userID)
+func (s *userService) GetUser(orgID, userID uint64) (*models.User, error) {
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
 }
 
-func (s *userService) ListUsers() ([]models.User, error) {
-	return s.userRepo.List()
+func (s *userService) UpdateUser(orgID, userID uint64, update UserUpdate) (*models.User, error) {
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.ErasedAt != nil {
+		return nil, ErrUserErased
+	}
+
+	var fullName string
+	if update.FullName != nil {
+		fullName = strings.TrimSpace(*update.FullName)
+		if fullName == "" {
+			return nil, errors.New("full name cannot be empty")
+		}
+	}
+
+	// The role goes first so that a refused role change leaves the name as
+	// it was
+	if update.Role != nil && *update.Role != user.Role {
+		if err := s.roleService.AssignRole(orgID, userID, *update.Role); err != nil {
+			return nil, err
+		}
+		user.Role = *update.Role
+	}
+
+	if update.FullName != nil {
+		user.FullName = fullName
+		if err := s.userRepo.UpdateProfile(user); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
 }
 
-func (s *userService) SetPassword(userID uint64, password string) error {
-	hashedPassword, err := utils.HashPassword(password)
+// ListUsers returns a page of the users matching the filter. The cursor is the
+// NextCursor of the previous page, or empty for the first page.
+func (s *userService) ListUsers(filter repositories.UserFilter, cursor string) (*UserPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUserPageSize
+	}
+	if filter.Limit > maxUserPageSize {
+		filter.Limit = maxUserPageSize
+	}
+
+	if cursor != "" {
+		var after repositories.UserCursor
+		if err := utils.DecodeCursor(cursor, &after); err != nil {
+			return nil, err
+		}
+		// A cursor only makes sense in the order it was made for
+		if after.Sort != filter.Sort || after.Descending != filter.Descending {
+			return nil, utils.ErrInvalidCursor
+		}
+		filter.After = &after
+	}
+
+	total, err := s.userRepo.Count(filter)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Fetch one extra user to learn whether another page follows
+	pageSize := filter.Limit
+	filter.Limit++
+	users, err := s.userRepo.List(filter)
+	if err != nil {
+		return nil, err
 	}
 
+	page := &UserPage{Users: users, Total: total}
+	if len(users) > pageSize {
+		page.Users = users[:pageSize]
+		next, err := utils.EncodeCursor(filter.CursorFor(&page.Users[pageSize-1]))
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = next
+	}
+	return page, nil
+}
+
+// ExportUsers passes every user matching the filter to write, in the filter's
+// order. Users are read in batches so that large directories are never held in
+// memory at once.
+func (s *userService) ExportUsers(filter repositories.UserFilter, write func(user *models.User) error) error {
+	filter.After = nil
+	filter.Limit = userExportBatchSize
+
+	for {
+		users, err := s.userRepo.List(filter)
+		if err != nil {
+			return err
+		}
+		for i := range users {
+			if err := write(&users[i]); err != nil {
+				return err
+			}
+		}
+		if len(users) < userExportBatchSize {
+			return nil
+		}
+
+		after := filter.CursorFor(&users[len(users)-1])
+		filter.After = &after
+	}
+}
+
+func (s *userService) SetPassword(userID uint64, password string) error {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
-		return err
+		return errors.New("user not found")
 	}
 
-	user.PasswordHash = &hashedPassword
-	user.AccountStatus = models.StatusActive
+	hashedPassword, err := s.passwordPolicy.Hash(user, password)
+	if err != nil {
+		return err
+	}
 
-	// This would typically be a separate repository method
-	// For simplicity, we'll assume the repository handles this
-	return nil
+	if err := s.userRepo.Activate(userID, hashedPassword); err != nil {
+		return err
+	}
+	return s.passwordPolicy.Remember(userID, hashedPassword)
 }
 
 func (s *userService) ChangePassword(userID uint64, currentPassword, newPassword string) error {
@@ -99,29 +315,73 @@ func (s *userService) ChangePassword(userID uint64, currentPassword, newPassword
 		return errors.New("current password is incorrect")
 	}
 
-	// Hash new password
-	hashedPassword, err := utils.HashPassword(newPassword)
+	// Check the new password against the policy and hash it
+	hashedPassword, err := s.passwordPolicy.Hash(user, newPassword)
 	if err != nil {
 		return err
 	}
 
 	// Update password in repository
-	return s.userRepo.UpdatePassword(userID, hashedPassword)
+	if err := s.userRepo.UpdatePassword(userID, hashedPassword); err != nil {
+		return err
+	}
+	return s.passwordPolicy.Remember(userID, hashedPassword)
 }
 
-func (s *userService) ResetPassword(userID uint64, newPassword string) error {
+func (s *userService) ResetPassword(orgID, userID uint64, newPassword string) error {
 	// Get user to verify they exist
-	_, err := s.userRepo.FindByID(userID)
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
 	if err != nil {
-		return errors.New("user not found")
+		return ErrUserNotFound
+	}
+	if user.ErasedAt != nil {
+		return ErrUserErased
 	}
 
-	// Hash new password
-	hashedPassword, err := utils.HashPassword(newPassword)
+	// Check the new password against the policy and hash it
+	hashedPassword, err := s.passwordPolicy.Hash(user, newPassword)
 	if err != nil {
 		return err
 	}
 
 	// Update password in repository (admin can reset without knowing current password)
-	return s.userRepo.UpdatePassword(userID, hashedPassword)
-}
\ No newline at end of file
+	if err := s.userRepo.UpdatePassword(userID, hashedPassword); err != nil {
+		return err
+	}
+	if err := s.passwordPolicy.Remember(userID, hashedPassword); err != nil {
+		return err
+	}
+
+	// Sign the user out everywhere
+	return s.authStateService.RevokeTokens(userID)
+}
+
+func (s *userService) UnlockUser(orgID, userID uint64) error {
+	user, err := s.userRepo.FindInOrganization(orgID, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.ErasedAt != nil {
+		return ErrUserErased
+	}
+
+	return s.userRepo.ResetLoginFailures(userID)
+}
+
+// ensureAdminRemains refuses to take the admin role or active status away from
+// the organization's last active admin. It lets a dry run report the problem
+// early; the repositories enforce the same rule inside the write itself.
+func ensureAdminRemains(userRepo repositories.UserRepository, user *models.User) error {
+	if user.Role != models.RoleAdmin || user.AccountStatus != models.StatusActive || user.IsServiceAccount {
+		return nil
+	}
+
+	count, err := userRepo.CountActiveAdmins(user.OrgID())
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return ErrLastAdmin
+	}
+	return nil
+}
@@ -15,51 +15,161 @@ type TokenType string
 const (
 	AccessToken  TokenType = "access"
 	RefreshToken TokenType = "refresh"
+	MFAToken     TokenType = "mfa"
+
+	// PasswordChangeToken lets a user whose password has expired set a new
+	// one before signing in.
+	PasswordChangeToken TokenType = "password_change"
 )
 
+// accessTokenHeaderType is the JOSE typ of access tokens (RFC 9068). Other
+// services verifying tokens against the published keys must check it, along
+// with the audience, so that refresh and challenge tokens signed with the
+// same keys are not taken for access tokens.
+const accessTokenHeaderType = "at+jwt"
+
 type Claims struct {
-	UserID uint64           `json:"user_id"`
-	Email  string           `json:"email"`
-	Role   models.UserRole  `json:"role"`
-	Type   TokenType        `json:"type"`
+	UserID         uint64          `json:"user_id"`
+	OrganizationID uint64          `json:"org,omitempty"`
+	Email          string          `json:"email"`
+	Role           models.UserRole `json:"role"`
+	Type           TokenType       `json:"type"`
+	SessionID      string          `json:"sid,omitempty"`
+	Version        int             `json:"ver"`
+	Actor          *ActorClaim     `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(user *models.User, tokenType TokenType, cfg *config.Config) (string, error) {
+// ActorClaim identifies the admin behind an impersonation token. The token's
+// own user fields describe the impersonated user, and its session ID is the
+// admin's session.
+type ActorClaim struct {
+	UserID          uint64 `json:"user_id"`
+	Email           string `json:"email"`
+	Version         int    `json:"ver"`
+	ImpersonationID uint64 `json:"imp"`
+}
+
+func GenerateToken(user *models.User, tokenType TokenType, sessionID string, cfg *config.Config) (string, error) {
 	var expiration time.Duration
-	
+
 	switch tokenType {
 	case AccessToken:
 		expiration = cfg.JWT.AccessTokenDuration
 	case RefreshToken:
 		expiration = cfg.JWT.RefreshTokenDuration
+	case MFAToken, PasswordChangeToken:
+		expiration = cfg.JWT.MFATokenDuration
 	default:
 		return "", errors.New("invalid token type")
 	}
 
+	tokenID, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
 	claims := Claims{
-		UserID: user.UserID,
-		Email:  user.Email,
-		Role:   user.Role,
-		Type:   tokenType,
+		UserID:         user.UserID,
+		OrganizationID: user.OrgID(),
+		Email:          user.Email,
+		Role:           user.Role,
+		Type:           tokenType,
+		SessionID:      sessionID,
+		Version:        user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "compass-backend",
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.JWT.Secret))
+	return signClaims(claims, cfg)
+}
+
+// GenerateImpersonationToken issues an access token for user that carries the
+// acting admin in its act claim. It cannot be refreshed.
+func GenerateImpersonationToken(user, actor *models.User, impersonationID uint64, sessionID string, expiresAt time.Time, cfg *config.Config) (string, error) {
+	tokenID, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims := Claims{
+		UserID:         user.UserID,
+		OrganizationID: user.OrgID(),
+		Email:          user.Email,
+		Role:           user.Role,
+		Type:           AccessToken,
+		SessionID:      sessionID,
+		Version:        user.TokenVersion,
+		Actor: &ActorClaim{
+			UserID:          actor.UserID,
+			Email:           actor.Email,
+			Version:         actor.TokenVersion,
+			ImpersonationID: impersonationID,
+		},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Issuer:    "compass-backend",
+		},
+	}
+
+	return signClaims(claims, cfg)
+}
+
+func signClaims(claims Claims, cfg *config.Config) (string, error) {
+	if claims.Type == AccessToken {
+		claims.Audience = jwt.ClaimStrings{cfg.JWT.Audience}
+	}
+
+	if cfg.JWT.ActiveKeyID == "" {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		setHeaderType(token, claims.Type)
+		return token.SignedString([]byte(cfg.JWT.Secret))
+	}
+
+	key := findSigningKey(cfg, cfg.JWT.ActiveKeyID)
+	if key == nil || key.Private == nil {
+		return "", errors.New("active signing key not loaded")
+	}
+
+	token := jwt.NewWithClaims(signingMethod(key.Public), claims)
+	token.Header["kid"] = key.ID
+	setHeaderType(token, claims.Type)
+	return token.SignedString(key.Private)
+}
+
+func setHeaderType(token *jwt.Token, tokenType TokenType) {
+	if tokenType == AccessToken {
+		token.Header["typ"] = accessTokenHeaderType
+	}
 }
 
 func ValidateToken(tokenString string, expectedType TokenType, cfg *config.Config) (*Claims, error) {
+	var options []jwt.ParserOption
+	if expectedType == AccessToken {
+		options = append(options, jwt.WithAudience(cfg.JWT.Audience))
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+		// Only access tokens carry the access token type
+		headerType, _ := token.Header["typ"].(string)
+		if (expectedType == AccessToken) != (headerType == accessTokenHeaderType) {
+			return nil, errors.New("invalid token type")
+		}
+
+		if cfg.JWT.ActiveKeyID == "" {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("unexpected signing method")
+			}
+			return []byte(cfg.JWT.Secret), nil
 		}
-		return []byte(cfg.JWT.Secret), nil
-	})
+		return verificationKey(token, cfg)
+	}, options...)
 
 	if err != nil {
 		return nil, err
@@ -77,16 +187,16 @@ func ValidateToken(tokenString string, expectedType TokenType, cfg *config.Confi
 	return claims, nil
 }
 
-func GenerateTokenPair(user *models.User, cfg *config.Config) (accessToken, refreshToken string, err error) {
-	accessToken, err = GenerateToken(user, AccessToken, cfg)
+func GenerateTokenPair(user *models.User, sessionID string, cfg *config.Config) (accessToken, refreshToken string, err error) {
+	accessToken, err = GenerateToken(user, AccessToken, sessionID, cfg)
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err = GenerateToken(user, RefreshToken, cfg)
+	refreshToken, err = GenerateToken(user, RefreshToken, sessionID, cfg)
 	if err != nil {
 		return "", "", err
 	}
 
 	return accessToken, refreshToken, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"compass-backend/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Small parameters keep the tests fast; the format is the same at any cost.
+func argon2Config(memory, iterations uint32) *config.Config {
+	return &config.Config{PasswordHash: config.PasswordHashConfig{
+		Algorithm:         HashArgon2id,
+		Argon2Memory:      memory,
+		Argon2Iterations:  iterations,
+		Argon2Parallelism: 1,
+	}}
+}
+
+func bcryptConfig(cost int) *config.Config {
+	return &config.Config{PasswordHash: config.PasswordHashConfig{
+		Algorithm:  HashBcrypt,
+		BcryptCost: cost,
+	}}
+}
+
+func mustHash(t *testing.T, password string, cfg *config.Config) string {
+	t.Helper()
+
+	hash, err := HashPassword(password, cfg)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	return hash
+}
+
+func TestCheckPassword(t *testing.T) {
+	argon2Hash := mustHash(t, "correct horse", argon2Config(64, 1))
+	bcryptHash := mustHash(t, "correct horse", bcryptConfig(bcrypt.MinCost))
+	parts := strings.Split(argon2Hash, "$")
+
+	tests := []struct {
+		name     string
+		password string
+		hash     string
+		valid    bool
+	}{
+		{name: "argon2id", password: "correct horse", hash: argon2Hash, valid: true},
+		{name: "argon2id wrong password", password: "battery staple", hash: argon2Hash, valid: false},
+		{name: "bcrypt", password: "correct horse", hash: bcryptHash, valid: true},
+		{name: "bcrypt wrong password", password: "battery staple", hash: bcryptHash, valid: false},
+		{name: "empty hash", password: "correct horse", hash: "", valid: false},
+		{name: "missing key", password: "correct horse", hash: strings.Join(parts[:5], "$"), valid: false},
+		{name: "empty key", password: "correct horse", hash: strings.Join(parts[:5], "$") + "$", valid: false},
+		{name: "extra field", password: "correct horse", hash: argon2Hash + "$extra", valid: false},
+		{name: "unsupported version", password: "correct horse", hash: strings.Replace(argon2Hash, "$v=19$", "$v=16$", 1), valid: false},
+		{name: "malformed parameters", password: "correct horse", hash: strings.Replace(argon2Hash, parts[3], "m=64;t=1;p=1", 1), valid: false},
+		{name: "salt not base64", password: "correct horse", hash: strings.Replace(argon2Hash, parts[4], "!!!", 1), valid: false},
+		{name: "key not base64", password: "correct horse", hash: strings.Replace(argon2Hash, parts[5], "!!!", 1), valid: false},
+		{name: "argon2i", password: "correct horse", hash: strings.Replace(argon2Hash, "$argon2id$", "$argon2i$", 1), valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPassword(tt.password, tt.hash); got != tt.valid {
+				t.Fatalf("expected %v, got %v", tt.valid, got)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	current := argon2Config(64, 1)
+	argon2Hash := mustHash(t, "correct horse", current)
+	bcryptHash := mustHash(t, "correct horse", bcryptConfig(bcrypt.MinCost))
+
+	tests := []struct {
+		name   string
+		hash   string
+		cfg    *config.Config
+		rehash bool
+	}{
+		{name: "current argon2id", hash: argon2Hash, cfg: current, rehash: false},
+		{name: "bcrypt to argon2id", hash: bcryptHash, cfg: current, rehash: true},
+		{name: "argon2 memory changed", hash: argon2Hash, cfg: argon2Config(128, 1), rehash: true},
+		{name: "argon2 iterations changed", hash: argon2Hash, cfg: argon2Config(64, 2), rehash: true},
+		{name: "malformed argon2id", hash: "$argon2id$v=19$m=64,t=1,p=1$", cfg: current, rehash: true},
+		{name: "current bcrypt", hash: bcryptHash, cfg: bcryptConfig(bcrypt.MinCost), rehash: false},
+		{name: "bcrypt cost changed", hash: bcryptHash, cfg: bcryptConfig(bcrypt.MinCost + 1), rehash: true},
+		{name: "argon2id to bcrypt", hash: argon2Hash, cfg: bcryptConfig(bcrypt.MinCost), rehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NeedsRehash(tt.hash, tt.cfg); got != tt.rehash {
+				t.Fatalf("expected %v, got %v", tt.rehash, got)
+			}
+		})
+	}
+}
+
+// A bcrypt hash keeps working after the switch to argon2id, and the rehash it
+// gets on the next sign-in verifies with the same password.
+func TestBcryptHashMigratesToArgon2id(t *testing.T) {
+	cfg := argon2Config(64, 1)
+	hash := mustHash(t, "correct horse", bcryptConfig(bcrypt.MinCost))
+
+	if !CheckPassword("correct horse", hash) || !NeedsRehash(hash, cfg) {
+		t.Fatal("expected the bcrypt hash to verify and need a rehash")
+	}
+
+	rehashed := mustHash(t, "correct horse", cfg)
+	if !strings.HasPrefix(rehashed, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("unexpected rehash format: %s", rehashed)
+	}
+	if !CheckPassword("correct horse", rehashed) || NeedsRehash(rehashed, cfg) {
+		t.Fatal("expected the argon2id rehash to verify and be current")
+	}
+}
@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is an in-memory fixed window limiter keyed by an arbitrary
+// string such as an e-mail address or client IP.
+type RateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*rateWindow
+}
+
+type rateWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*rateWindow),
+	}
+}
+
+// Allow records a hit for key and reports whether it is within the limit.
+func (l *RateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	w, ok := l.windows[key]
+	if !ok || now.After(w.resetAt) {
+		w = &rateWindow{resetAt: now.Add(l.window)}
+		l.windows[key] = w
+	}
+
+	w.count++
+	return w.count <= l.limit
+}
+
+// sweep drops expired windows once the map grows large so that it does not
+// keep every key ever seen.
+func (l *RateLimiter) sweep(now time.Time) {
+	if len(l.windows) < 10000 {
+		return
+	}
+	for key, w := range l.windows {
+		if now.After(w.resetAt) {
+			delete(l.windows, key)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"time"
+
+	"compass-backend/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWK is the public part of a signing key as published in the JWKS document.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS lists every key that currently verifies tokens, including retired
+// keys that are still within their grace period, along with upcoming keys so
+// that clients know them before they sign. It is empty while tokens are
+// signed with the shared secret.
+func PublicJWKS(cfg *config.Config) JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range cfg.JWT.SigningKeys {
+		if !keyVerifies(key, cfg, time.Now()) {
+			continue
+		}
+
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: signingMethod(key.Public).Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// verificationKey picks the public key named by the token's kid and checks
+// that the token was signed with the algorithm that belongs to it.
+func verificationKey(token *jwt.Token, cfg *config.Config) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key := findSigningKey(cfg, kid)
+	if key == nil || key.Upcoming || !keyVerifies(*key, cfg, time.Now()) {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != signingMethod(key.Public).Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.Public, nil
+}
+
+func findSigningKey(cfg *config.Config, kid string) *config.SigningKey {
+	for i := range cfg.JWT.SigningKeys {
+		if cfg.JWT.SigningKeys[i].ID == kid {
+			return &cfg.JWT.SigningKeys[i]
+		}
+	}
+	return nil
+}
+
+func keyVerifies(key config.SigningKey, cfg *config.Config, now time.Time) bool {
+	until := key.VerifiesUntil(cfg.JWT.KeyGracePeriod)
+	return until.IsZero() || now.Before(until)
+}
+
+func signingMethod(public crypto.PublicKey) jwt.SigningMethod {
+	if _, ok := public.(ed25519.PublicKey); ok {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
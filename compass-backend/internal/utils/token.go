@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// GenerateRandomToken returns a hex encoded string built from n random bytes.
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns the hex encoded SHA-256 digest of a token. Tokens that are
+// persisted server-side are only ever stored in this form.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateSignedToken returns a random token with an HMAC-SHA256 signature
+// appended, so forged or mangled tokens can be rejected before any lookup.
+func GenerateSignedToken(secret string) (string, error) {
+	raw, err := GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	return raw + "." + signToken(raw, secret), nil
+}
+
+// VerifySignedToken checks the signature of a token created by
+// GenerateSignedToken.
+func VerifySignedToken(token, secret string) bool {
+	raw, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(signToken(raw, secret)))
+}
+
+func signToken(raw, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(raw))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors,
+// "12345678901234567890", in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The RFC 6238 SHA-1 test vectors. The RFC lists 8-digit codes; the 6-digit
+// codes used here are their last six digits.
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestValidateTOTPAcceptsRFC6238Vectors(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		now := time.Unix(v.unix, 0)
+		step := v.unix / totpPeriod
+
+		tests := []struct {
+			name   string
+			offset time.Duration
+			valid  bool
+		}{
+			{name: "same step", offset: 0, valid: true},
+			{name: "one step late", offset: totpPeriod * time.Second, valid: true},
+			{name: "one step early", offset: -totpPeriod * time.Second, valid: true},
+			{name: "two steps late", offset: 2 * totpPeriod * time.Second, valid: false},
+			{name: "two steps early", offset: -2 * totpPeriod * time.Second, valid: false},
+		}
+
+		for _, tt := range tests {
+			t.Run(v.code+"/"+tt.name, func(t *testing.T) {
+				// Steps are only defined from the Unix epoch on
+				if now.Add(tt.offset).Unix() < 0 {
+					t.Skip("before the Unix epoch")
+				}
+				got, ok := ValidateTOTP(rfc6238Secret, v.code, now.Add(tt.offset))
+				if ok != tt.valid {
+					t.Fatalf("expected valid=%v, got %v", tt.valid, ok)
+				}
+				if ok && got != step {
+					t.Fatalf("expected step %d, got %d", step, got)
+				}
+			})
+		}
+	}
+}
+
+func TestValidateTOTPRejectsMalformedInput(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		valid  bool
+	}{
+		{name: "lower-case secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "287082", valid: true},
+		{name: "surrounding space", secret: rfc6238Secret, code: " 287082 ", valid: true},
+		{name: "wrong code", secret: rfc6238Secret, code: "287083", valid: false},
+		{name: "eight digits", secret: rfc6238Secret, code: "94287082", valid: false},
+		{name: "too short", secret: rfc6238Secret, code: "28708", valid: false},
+		{name: "empty code", secret: rfc6238Secret, code: "", valid: false},
+		{name: "invalid secret", secret: "not base32!", code: "287082", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now); ok != tt.valid {
+				t.Fatalf("expected valid=%v, got %v", tt.valid, ok)
+			}
+		})
+	}
+}